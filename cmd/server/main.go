@@ -3,11 +3,16 @@ package main
 import (
 	"Test/config"
 	"Test/internal/auth"
+	"Test/internal/events"
+	"Test/internal/gateway"
 	"Test/internal/handler"
+	"Test/internal/levels"
 	"Test/internal/middleware"
 	"Test/internal/repository"
+	"Test/internal/scheduler"
 	"Test/internal/service"
 	"Test/internal/storage"
+	"context"
 	"log"
 	"net/http"
 	"time"
@@ -35,13 +40,98 @@ func main() {
 
 	userRepo := repository.NewUserRepo(db.DB)
 	taskRepo := repository.NewTaskRepo(db.DB)
+	referralRepo := repository.NewReferralRepo(db.DB)
+	seasonRepo := repository.NewSeasonRepo(db.DB)
+	rewardRepo := repository.NewRewardRepo(db.DB)
+	promoRepo := repository.NewPromoRepo(db.DB)
+	transferRepo := repository.NewTransferRepo(db.DB)
+	expiryRepo := repository.NewExpiryRepo(db.DB)
+	badgeRepo := repository.NewBadgeRepo(db.DB)
+	checkinRepo := repository.NewCheckinRepo(db.DB)
+	wheelRepo := repository.NewWheelRepo(db.DB)
+	raffleRepo := repository.NewRaffleRepo(db.DB)
+	teamRepo := repository.NewTeamRepo(db.DB)
+	communityRepo := repository.NewCommunityRepo(db.DB)
 
-	userService := service.NewUserService(userRepo, taskRepo)
+	bus := events.NewBus(cfg.Stream.HistorySize)
+
+	expiryService := service.NewExpiryService(expiryRepo, cfg.Expiry, bus)
+	curve, err := levels.New(cfg.Levels)
+	if err != nil {
+		log.Fatalf("load levels: %v", err)
+	}
+	badgeService := service.NewBadgeService(badgeRepo, bus)
+	checkinService, err := service.NewCheckinService(checkinRepo, cfg.Checkin, bus)
+	if err != nil {
+		log.Fatalf("init checkin service: %v", err)
+	}
+	userService := service.NewUserService(userRepo, taskRepo, cfg.Referral.LevelRates(), bus, expiryService, curve, badgeService, checkinService)
+	referralService := service.NewReferralService(referralRepo, cfg.Referral, cfg.Fraud, bus)
+	leaderboardService, err := service.NewLeaderboardService(userRepo, cfg.Leaderboard, bus)
+	if err != nil {
+		log.Fatalf("init leaderboard: %v", err)
+	}
+	seasonService := service.NewSeasonService(seasonRepo, userRepo, bus)
+	rewardService := service.NewRewardService(rewardRepo, bus)
+	promoService := service.NewPromoService(promoRepo, bus)
+	transferService := service.NewTransferService(transferRepo, cfg.Transfers, bus)
+	wheelService, err := service.NewWheelService(wheelRepo, cfg.Wheel, bus)
+	if err != nil {
+		log.Fatalf("init wheel service: %v", err)
+	}
+	raffleService := service.NewRaffleService(raffleRepo, bus)
+	teamService := service.NewTeamService(teamRepo, cfg.Teams, bus)
+	communityService := service.NewCommunityService(communityRepo, cfg.Community, bus)
+
+	if err := leaderboardService.WarmIndex(context.Background()); err != nil {
+		log.Fatalf("warm ranking index: %v", err)
+	}
 	jwtService := middleware.NewJWTService(cfg.JWT.SecretKey)
 
 	userHandler := handler.NewUserHandler(userService)
+	referralHandler := handler.NewReferralHandler(referralService)
+	leaderboardHandler := handler.NewLeaderboardHandler(leaderboardService)
+	seasonHandler := handler.NewSeasonHandler(seasonService)
+	rewardHandler := handler.NewRewardHandler(rewardService)
+	promoHandler := handler.NewPromoHandler(promoService)
+	transferHandler := handler.NewTransferHandler(transferService)
+	badgeHandler := handler.NewBadgeHandler(badgeService)
+	checkinHandler := handler.NewCheckinHandler(checkinService)
+	wheelHandler := handler.NewWheelHandler(wheelService)
+	raffleHandler := handler.NewRaffleHandler(raffleService)
+	teamHandler := handler.NewTeamHandler(teamService)
+	communityHandler := handler.NewCommunityHandler(communityService)
+	streamHandler := handler.NewStreamHandler(bus, cfg.Stream.HeartbeatInterval, cfg.Stream.ClientBuffer)
+	hub := gateway.NewHub(bus, cfg.Stream.ClientBuffer)
+	wsHandler := handler.NewWSHandler(hub, cfg.Stream.HeartbeatInterval)
 	authHandler := auth.NewAuthHandler(userRepo, cfg)
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go hub.Run(ctx)
+	go badgeService.Run(ctx)
+	go teamService.Run(ctx)
+	go leaderboardService.Run(ctx)
+	go scheduler.Every(ctx, cfg.Referral.EvaluateInterval, "referral rewards", referralService.EvaluatePending)
+	go scheduler.Every(ctx, cfg.Leaderboard.SeasonSnapshotInterval, "season snapshots", seasonService.SnapshotDue)
+	go scheduler.Every(ctx, cfg.Raffles.DrawInterval, "raffle draws", raffleService.DrawDue)
+	go scheduler.Every(ctx, cfg.Community.ProgressInterval, "community goal progress", communityService.PublishProgress)
+	go scheduler.Every(ctx, cfg.Community.PayoutInterval, "community goal payouts", communityService.PayoutDue)
+	if cfg.Leaderboard.IndexSyncInterval > 0 {
+		go scheduler.Every(ctx, cfg.Leaderboard.IndexSyncInterval, "ranking index sync", leaderboardService.SyncIndex)
+		go scheduler.Every(ctx, cfg.Leaderboard.IndexCheckInterval, "ranking index check", func(ctx context.Context) error {
+			_, err := leaderboardService.CheckIndex(ctx)
+			return err
+		})
+	}
+	if cfg.Expiry.Active() {
+		go scheduler.Every(ctx, cfg.Expiry.Interval, "points expiry", expiryService.ExpireDue)
+	}
+	go scheduler.Every(ctx, cfg.Stream.LeaderboardInterval, "leaderboard updates", func(ctx context.Context) error {
+		return leaderboardService.PublishTopChanges(ctx, cfg.Stream.LeaderboardTop)
+	})
+
 	router := gin.Default()
 
 	router.Use(cors.New(cors.Config{
@@ -61,6 +151,7 @@ func main() {
 	{
 		api.POST("/register", authHandler.RegisterHandler)
 		api.POST("/login", authHandler.LoginHandler)
+		api.GET("/invite/:referrer_id", referralHandler.TrackInvite)
 
 		authorized := api.Group("")
 		authorized.Use(middleware.AuthMiddleware(jwtService))
@@ -68,9 +159,98 @@ func main() {
 			users := authorized.Group("/users")
 			{
 				users.GET("/:id/status", userHandler.GetUserStatus)
-				users.POST("/:id/task/complete", userHandler.CompleteTask)
-				users.POST("/:id/referrer", userHandler.SetReferrer)
-				users.GET("/leaderboard", userHandler.GetLeaderboard)
+				users.GET("/:id/profile", userHandler.GetProfile)
+				users.POST("/:id/task/complete", middleware.RequireSelf(), userHandler.CompleteTask)
+				users.POST("/:id/referrer", middleware.RequireSelf(), referralHandler.SetReferrer)
+				users.GET("/:id/referrals", middleware.RequireSelf(), referralHandler.ListInvited)
+				users.GET("/:id/referrals/tree", middleware.RequireSelf(), referralHandler.GetReferralTree)
+				users.GET("/:id/referrals/stats", middleware.RequireSelf(), referralHandler.GetUserStats)
+				users.GET("/:id/friends", middleware.RequireSelf(), userHandler.ListFriends)
+				users.POST("/:id/friends", middleware.RequireSelf(), userHandler.AddFriend)
+				users.DELETE("/:id/friends/:friend_id", middleware.RequireSelf(), userHandler.RemoveFriend)
+				users.GET("/:id/orders", middleware.RequireSelf(), rewardHandler.ListUserOrders)
+				users.GET("/:id/transfers", middleware.RequireSelf(), transferHandler.ListTransfers)
+				users.POST("/:id/transfers", middleware.RequireSelf(), transferHandler.CreateTransfer)
+				users.POST("/:id/checkin", middleware.RequireSelf(), checkinHandler.CheckIn)
+				users.POST("/:id/streak/freezes", middleware.RequireSelf(), checkinHandler.BuyFreeze)
+				users.POST("/:id/wheel/spin", middleware.RequireSelf(), wheelHandler.Spin)
+				users.GET("/:id/wheel/seed", middleware.RequireSelf(), wheelHandler.GetSeed)
+				users.POST("/:id/wheel/seed", middleware.RequireSelf(), wheelHandler.RotateSeed)
+				users.GET("/:id/wheel/spins", middleware.RequireSelf(), wheelHandler.ListUserSpins)
+				users.GET("/:id/team", teamHandler.GetUserTeam)
+				users.GET("/leaderboard", leaderboardHandler.GetLeaderboard)
+			}
+
+			leaderboard := authorized.Group("/leaderboard")
+			{
+				leaderboard.GET("", leaderboardHandler.GetLeaderboard)
+				leaderboard.GET("/seasons", seasonHandler.ListSeasons)
+				leaderboard.GET("/seasons/:id", seasonHandler.GetStandings)
+			}
+
+			rewards := authorized.Group("/rewards")
+			{
+				rewards.GET("", rewardHandler.ListCatalog)
+				rewards.POST("/:id/redeem", rewardHandler.Redeem)
+			}
+
+			raffles := authorized.Group("/raffles")
+			{
+				raffles.GET("", raffleHandler.ListRaffles)
+				raffles.GET("/:id", raffleHandler.GetRaffle)
+				raffles.GET("/:id/entries", raffleHandler.ListEntries)
+				raffles.POST("/:id/tickets", raffleHandler.BuyTickets)
+			}
+
+			teams := authorized.Group("/teams")
+			{
+				teams.POST("", teamHandler.CreateTeam)
+				teams.GET("/leaderboard", teamHandler.GetLeaderboard)
+				teams.POST("/join", teamHandler.JoinTeam)
+				teams.GET("/:id", teamHandler.GetTeam)
+				teams.POST("/:id/leave", teamHandler.LeaveTeam)
+				teams.DELETE("/:id/members/:user_id", teamHandler.RemoveMember)
+				teams.PUT("/:id/captain", teamHandler.SetCaptain)
+				teams.POST("/:id/invite-code", teamHandler.RotateInviteCode)
+				teams.GET("/:id/challenges", teamHandler.ListChallenges)
+			}
+
+			authorized.GET("/community-goals", communityHandler.ListGoals)
+			authorized.GET("/community-goals/:id", communityHandler.GetGoal)
+			authorized.POST("/promo/redeem", promoHandler.Redeem)
+			authorized.GET("/badges", badgeHandler.ListBadges)
+			authorized.GET("/wheel", wheelHandler.GetTable)
+			authorized.GET("/stream", streamHandler.Stream)
+			authorized.GET("/ws", wsHandler.Connect)
+
+			admin := authorized.Group("/admin")
+			admin.Use(middleware.RequireAdmin(userRepo))
+			{
+				admin.GET("/referrals/flagged", referralHandler.ListFlagged)
+				admin.POST("/referrals/:referee_id/resolve", referralHandler.ResolveFlagged)
+				admin.GET("/referrals/stats", referralHandler.GetStatsReport)
+				admin.POST("/seasons", seasonHandler.CreateSeason)
+				admin.GET("/leaderboard/consistency", leaderboardHandler.CheckIndex)
+				admin.PUT("/users/:id/cohorts", userHandler.SetCohort)
+				admin.PUT("/users/:id/email-verified", userHandler.SetEmailVerified)
+				admin.PUT("/users/:id/leaderboard", leaderboardHandler.SetVisibility)
+				admin.GET("/leaderboard/hidden", leaderboardHandler.ListHidden)
+				admin.GET("/rewards", rewardHandler.ListRewards)
+				admin.POST("/rewards", rewardHandler.CreateReward)
+				admin.PUT("/rewards/:id", rewardHandler.UpdateReward)
+				admin.GET("/orders", rewardHandler.ListOrders)
+				admin.POST("/orders/:id/resolve", rewardHandler.ResolveOrder)
+				admin.GET("/promo/batches", promoHandler.ListBatches)
+				admin.POST("/promo/batches", promoHandler.CreateBatch)
+				admin.GET("/promo/batches/:id/codes.csv", promoHandler.ExportCodes)
+				admin.POST("/badges", badgeHandler.CreateBadge)
+				admin.PUT("/wheel/prizes", wheelHandler.SetPrizes)
+				admin.GET("/wheel/report", wheelHandler.Report)
+				admin.GET("/wheel/spins", wheelHandler.ListAllSpins)
+				admin.POST("/raffles", raffleHandler.CreateRaffle)
+				admin.POST("/raffles/:id/cancel", raffleHandler.CancelRaffle)
+				admin.POST("/teams/:id/challenges", teamHandler.CreateChallenge)
+				admin.POST("/community-goals", communityHandler.CreateGoal)
 			}
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
@@ -23,6 +24,126 @@ type Config struct {
 		SecretKey  string        `yaml:"secret_key"`
 		Expiration time.Duration `yaml:"expiration"`
 	} `yaml:"jwt"`
+	Referral    ReferralConfig    `yaml:"referral"`
+	Fraud       FraudConfig       `yaml:"fraud"`
+	Leaderboard LeaderboardConfig `yaml:"leaderboard"`
+	Stream      StreamConfig      `yaml:"stream"`
+	Transfers   TransfersConfig   `yaml:"transfers"`
+	Expiry      ExpiryConfig      `yaml:"expiry"`
+	Levels      LevelsConfig      `yaml:"levels"`
+	Checkin     CheckinConfig     `yaml:"checkin"`
+	Wheel       WheelConfig       `yaml:"wheel"`
+	Raffles     RafflesConfig     `yaml:"raffles"`
+	Teams       TeamsConfig       `yaml:"teams"`
+	Community   CommunityConfig   `yaml:"community"`
+}
+
+// ReferralConfig описывает награду рефереру и условия, при которых она выплачивается.
+type ReferralConfig struct {
+	RewardPoints         int           `yaml:"reward_points"`
+	RequireVerifiedEmail bool          `yaml:"require_verified_email"`
+	MinCompletedTasks    int           `yaml:"min_completed_tasks"`
+	MinDaysActive        int           `yaml:"min_days_active"`
+	QualifyWithin        time.Duration `yaml:"qualify_within"`
+	EvaluateInterval     time.Duration `yaml:"evaluate_interval"`
+	// Доля очков за задание, которую получает реферер на каждом уровне вверх по цепочке
+	CommissionRates []float64 `yaml:"commission_rates"`
+	MaxDepth        int       `yaml:"max_depth"`
+	// Куда перенаправлять переход по пригласительной ссылке; ID реферера добавляется как ?ref=
+	InviteRedirectURL string `yaml:"invite_redirect_url"`
+}
+
+// FraudConfig задаёт пороги для сигналов накрутки рефералов.
+type FraudConfig struct {
+	BurstWindow    time.Duration `yaml:"burst_window"`
+	BurstThreshold int           `yaml:"burst_threshold"`
+}
+
+// LeaderboardConfig задаёт границы периодов рейтинга: часовой пояс и первый день недели.
+type LeaderboardConfig struct {
+	Timezone  string `yaml:"timezone"`
+	WeekStart string `yaml:"week_start"`
+	// Как часто проверять завершившиеся сезоны
+	SeasonSnapshotInterval time.Duration `yaml:"season_snapshot_interval"`
+	// In-memory индекс рейтинга за всё время обновляется по событиям, index_sync_interval — период
+	// досинхронизации с базой; при index_sync_interval = 0 рейтинг считается в PostgreSQL
+	IndexSyncInterval  time.Duration `yaml:"index_sync_interval"`
+	IndexCheckInterval time.Duration `yaml:"index_check_interval"`
+}
+
+// StreamConfig задаёт параметры потока событий (SSE).
+type StreamConfig struct {
+	HeartbeatInterval time.Duration `yaml:"heartbeat_interval"`
+	// Сколько последних событий хранится для докачки по Last-Event-ID
+	HistorySize int `yaml:"history_size"`
+	// Буфер событий на клиента; медленный клиент отключается при переполнении
+	ClientBuffer int `yaml:"client_buffer"`
+	// Изменения в топ-N рейтинга рассылаются всем подписчикам
+	LeaderboardTop      int           `yaml:"leaderboard_top"`
+	LeaderboardInterval time.Duration `yaml:"leaderboard_interval"`
+}
+
+// TransfersConfig ограничивает переводы очков между пользователями за скользящие 24 часа.
+type TransfersConfig struct {
+	DailyAmount int `yaml:"daily_amount"`
+	DailyCount  int `yaml:"daily_count"`
+}
+
+// ExpiryConfig задаёт сгорание очков: каждая партия начисленных очков сгорает
+// через Months месяцев после начисления.
+type ExpiryConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Months  int  `yaml:"months"`
+	// За сколько до сгорания показывать очки в статусе пользователя
+	WarnBefore time.Duration `yaml:"warn_before"`
+	Interval   time.Duration `yaml:"interval"`
+	// Сколько пользователей обрабатывается за один запрос к базе
+	BatchSize int `yaml:"batch_size"`
+}
+
+// Active сообщает, включено ли сгорание очков.
+func (c ExpiryConfig) Active() bool {
+	return c.Enabled && c.Months > 0
+}
+
+// LevelsConfig задаёт кривую уровней: Thresholds[i] — опыт, с которого начинается
+// уровень i+1. Тиры открываются на уровне MinLevel и умножают очки за задания.
+type LevelsConfig struct {
+	Thresholds []int        `yaml:"thresholds"`
+	Tiers      []TierConfig `yaml:"tiers"`
+}
+
+type TierConfig struct {
+	Name       string  `yaml:"name"`
+	MinLevel   int     `yaml:"min_level"`
+	Multiplier float64 `yaml:"multiplier"`
+}
+
+// CheckinConfig задаёт ежедневные отметки: Rewards[i] — очки за (i+1)-й день серии подряд,
+// дальше последней ступени награда не растёт.
+type CheckinConfig struct {
+	// Часовой пояс по умолчанию, если пользователь не передал свой
+	Timezone string `yaml:"timezone"`
+	Rewards  []int  `yaml:"rewards"`
+	// Заморозка покрывает один пропущенный день и покупается за очки
+	FreezeCost int `yaml:"freeze_cost"`
+	MaxFreezes int `yaml:"max_freezes"`
+}
+
+// Reward возвращает награду за day-й день серии подряд.
+func (c CheckinConfig) Reward(day int) int {
+	if len(c.Rewards) == 0 || day <= 0 {
+		return 0
+	}
+	return c.Rewards[min(day, len(c.Rewards))-1]
+}
+
+// WheelConfig задаёт стоимость вращения колеса и бесплатные вращения в сутки.
+type WheelConfig struct {
+	SpinCost        int `yaml:"spin_cost"`
+	FreeSpinsPerDay int `yaml:"free_spins_per_day"`
+	// Часовой пояс, в котором считаются сутки для бесплатных вращений
+	Timezone string `yaml:"timezone"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -34,9 +155,49 @@ func LoadConfig(path string) (*Config, error) {
 	}
 
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := viper.Unmarshal(&cfg, func(dc *mapstructure.DecoderConfig) {
+		dc.TagName = "yaml"
+	}); err != nil {
 		return nil, fmt.Errorf("unmarshal config: %w", err)
 	}
 
 	return &cfg, nil
 }
+
+// maxReferralDepth ограничивает глубину реферальной цепочки независимо от настроек.
+const maxReferralDepth = 10
+
+// LevelRates возвращает ставки комиссий, обрезанные до допустимой глубины.
+func (c ReferralConfig) LevelRates() []float64 {
+	depth := c.Depth()
+	if len(c.CommissionRates) < depth {
+		depth = len(c.CommissionRates)
+	}
+	return c.CommissionRates[:depth]
+}
+
+// Depth возвращает глубину дерева рефералов с учётом жёсткого ограничения.
+func (c ReferralConfig) Depth() int {
+	if c.MaxDepth <= 0 || c.MaxDepth > maxReferralDepth {
+		return maxReferralDepth
+	}
+	return c.MaxDepth
+}
+
+// RafflesConfig задаёт, как часто проверять розыгрыши, время которых наступило.
+type RafflesConfig struct {
+	DrawInterval time.Duration `yaml:"draw_interval"`
+}
+
+// TeamsConfig ограничивает размер команды.
+type TeamsConfig struct {
+	MaxMembers int `yaml:"max_members"`
+}
+
+// CommunityConfig задаёт фоновые задачи общих целей: рассылку прогресса и выплату наград.
+type CommunityConfig struct {
+	ProgressInterval time.Duration `yaml:"progress_interval"`
+	PayoutInterval   time.Duration `yaml:"payout_interval"`
+	// Сколько участников награждается в одной транзакции
+	BatchSize int `yaml:"batch_size"`
+}
@@ -2,6 +2,7 @@ package auth
 
 import (
 	"Test/config"
+	"Test/internal/model"
 	"Test/internal/repository"
 	"fmt"
 	"log"
@@ -38,6 +39,8 @@ func (h *AuthHandler) RegisterHandler(c *gin.Context) {
 		Username string `json:"username" binding:"required"`
 		Email    string `json:"email" binding:"required"`
 		Password string `json:"password" binding:"required"`
+		DeviceID string `json:"device_id"`
+		Country  string `json:"country"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -50,7 +53,19 @@ func (h *AuthHandler) RegisterHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.service.Register(c.Request.Context(), req.Username, req.Password, req.Email)
+	info := model.RegistrationInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  req.DeviceID,
+		Country:   strings.ToUpper(strings.TrimSpace(req.Country)),
+	}
+
+	if info.Country != "" && len(info.Country) != 2 {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "country must be an ISO 3166-1 alpha-2 code", "", "country")
+		return
+	}
+
+	err := h.service.Register(c.Request.Context(), req.Username, req.Password, req.Email, info)
 	if err != nil {
 		h.handleServiceError(c, err, req.Email)
 		return
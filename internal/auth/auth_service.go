@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"Test/internal/fraud"
 	"Test/internal/model"
 	"Test/internal/repository"
 	"context"
@@ -60,7 +61,7 @@ func NewAuthService(repo repository.UserRepository, jwt *JWTService) *AuthServic
 	return &AuthService{repo: repo, jwtService: jwt}
 }
 
-func (s *AuthService) Register(ctx context.Context, username, password, email string) error {
+func (s *AuthService) Register(ctx context.Context, username, password, email string, info model.RegistrationInfo) error {
 	if err := validatePassword(password); err != nil {
 		return err
 	}
@@ -79,12 +80,14 @@ func (s *AuthService) Register(ctx context.Context, username, password, email st
 	}
 
 	user := &model.User{
-		ID:        uuid.NewString(),
-		Name:      username,
-		Email:     email,
-		Password:  string(hash),
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:              uuid.NewString(),
+		Name:            username,
+		Email:           email,
+		Password:        string(hash),
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+		NormalizedEmail: fraud.NormalizeEmail(email),
+		Registration:    info,
 	}
 
 	if err := s.repo.CreateUser(ctx, user); err != nil {
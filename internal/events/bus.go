@@ -0,0 +1,169 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+type Type string
+
+const (
+	BalanceChanged     Type = "balance"
+	TaskCompleted      Type = "task_completed"
+	ReferralJoined     Type = "referral_joined"
+	ReferralPaid       Type = "referral_paid"
+	LeaderboardChanged Type = "leaderboard"
+	TransferReceived   Type = "transfer_received"
+	LevelUp            Type = "level_up"
+	SeasonEnded        Type = "season_ended"
+	BadgeAwarded       Type = "badge_awarded"
+	CheckedIn          Type = "checkin"
+	RaffleDrawn        Type = "raffle_drawn"
+	TeamChallengeDone  Type = "team_challenge_completed"
+	CommunityProgress  Type = "community_goal_progress"
+	CommunityReached   Type = "community_goal_reached"
+)
+
+// Event — событие шины. Пустой UserID означает событие для всех.
+type Event struct {
+	ID     uint64    `json:"id"`
+	Type   Type      `json:"type"`
+	UserID string    `json:"user_id,omitempty"`
+	Data   any       `json:"data"`
+	At     time.Time `json:"at"`
+}
+
+// Bus — внутрипроцессная шина событий с кольцевой историей для докачки
+// пропущенных событий по Last-Event-ID.
+type Bus struct {
+	mu      sync.RWMutex
+	nextID  uint64
+	history []Event
+	start   int
+	subs    map[*Subscription]struct{}
+}
+
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = 1
+	}
+	return &Bus{
+		nextID:  1,
+		history: make([]Event, 0, historySize),
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish рассылает событие подписчикам. Отправка не блокируется: подписчик,
+// чей буфер переполнен, отключается и должен переподключиться с Last-Event-ID.
+func (b *Bus) Publish(typ Type, userID string, data any) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	event := Event{
+		ID:     b.nextID,
+		Type:   typ,
+		UserID: userID,
+		Data:   data,
+		At:     time.Now(),
+	}
+	b.nextID++
+
+	if len(b.history) < cap(b.history) {
+		b.history = append(b.history, event)
+	} else {
+		b.history[b.start] = event
+		b.start = (b.start + 1) % len(b.history)
+	}
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.drop(sub)
+		}
+	}
+
+	return event
+}
+
+// Subscribe подписывает на события, прошедшие filter (nil — все события).
+func (b *Bus) Subscribe(filter func(Event) bool, buffer int) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		bus:    b,
+		filter: filter,
+		ch:     make(chan Event, buffer),
+	}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Since возвращает события из истории с ID больше lastID. ok == false, если часть
+// событий уже вытеснена из истории (или lastID из другого запуска) и клиенту
+// нужно заново запросить состояние.
+func (b *Bus) Since(lastID uint64, filter func(Event) bool) (events []Event, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if lastID >= b.nextID {
+		return nil, false
+	}
+	ok = len(b.history) == 0 || b.history[b.start].ID <= lastID+1
+
+	for i := 0; i < len(b.history); i++ {
+		event := b.history[(b.start+i)%len(b.history)]
+		if event.ID <= lastID {
+			continue
+		}
+		if filter != nil && !filter(event) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, ok
+}
+
+func (b *Bus) drop(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.lagged = true
+	close(sub.ch)
+}
+
+type Subscription struct {
+	bus    *Bus
+	filter func(Event) bool
+	ch     chan Event
+	lagged bool
+}
+
+// C возвращает канал событий; он закрывается при отписке или переполнении буфера.
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+// Lagged сообщает, что подписка была отключена из-за медленного потребителя.
+func (s *Subscription) Lagged() bool {
+	s.bus.mu.RLock()
+	defer s.bus.mu.RUnlock()
+	return s.lagged
+}
+
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+
+	if _, ok := s.bus.subs[s]; !ok {
+		return
+	}
+	delete(s.bus.subs, s)
+	close(s.ch)
+}
@@ -0,0 +1,86 @@
+package fairness
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// rollBits — сколько старших бит HMAC превращается в число из [0, 1)
+const rollBits = 52
+
+// NewSeed возвращает случайный сид из n байт в hex.
+func NewSeed(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate seed: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Hash — хеш серверного сида, который пользователь видит до розыгрышей: hex(SHA-256(serverSeed)).
+// Сам сид раскрывается при смене, и по нему можно пересчитать каждый результат.
+func Hash(serverSeed string) string {
+	sum := sha256.Sum256([]byte(serverSeed))
+	return hex.EncodeToString(sum[:])
+}
+
+// Roll возвращает hex(HMAC-SHA256(serverSeed, "clientSeed:nonce")) и число из [0, 1),
+// составленное из первых 13 hex-символов (52 бит) этого HMAC.
+func Roll(serverSeed, clientSeed string, nonce int) (digest string, value float64) {
+	mac := hmac.New(sha256.New, []byte(serverSeed))
+	mac.Write([]byte(clientSeed + ":" + strconv.Itoa(nonce)))
+	digest = hex.EncodeToString(mac.Sum(nil))
+
+	n, _ := strconv.ParseUint(digest[:rollBits/4], 16, 64)
+	return digest, float64(n) / float64(uint64(1)<<rollBits)
+}
+
+// Pick выбирает индекс по весам: value * сумма весов попадает в накопленный вес приза.
+func Pick(value float64, weights []int) int {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+
+	target := int(value * float64(total))
+	for i, w := range weights {
+		if target < w {
+			return i
+		}
+		target -= w
+	}
+	return len(weights) - 1
+}
+
+// Result — один выбор Draw: индекс, nonce, с которым он сделан, и HMAC этого броска.
+type Result struct {
+	Index  int
+	Nonce  int
+	Digest string
+}
+
+// Draw выбирает до n разных индексов по весам без возвращения: k-й выбор делается
+// Roll с nonce = k, после чего выбранный индекс исключается из следующих бросков.
+func Draw(serverSeed, clientSeed string, weights []int, n int) []Result {
+	remaining := make([]int, len(weights))
+	candidates := 0
+	for i, w := range weights {
+		if w > 0 {
+			remaining[i] = w
+			candidates++
+		}
+	}
+
+	results := make([]Result, 0, min(n, candidates))
+	for nonce := 0; nonce < n && nonce < candidates; nonce++ {
+		digest, value := Roll(serverSeed, clientSeed, nonce)
+		i := Pick(value, remaining)
+		remaining[i] = 0
+		results = append(results, Result{Index: i, Nonce: nonce, Digest: digest})
+	}
+	return results
+}
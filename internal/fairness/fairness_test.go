@@ -0,0 +1,131 @@
+package fairness
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	want := "91024ec49c5bec0b689e42892526320fce08337205c91de94c7a588c20d08eeb"
+	if got := Hash("server-seed"); got != want {
+		t.Fatalf("Hash() = %s, want %s", got, want)
+	}
+}
+
+func TestRoll(t *testing.T) {
+	tests := []struct {
+		serverSeed, clientSeed string
+		nonce                  int
+		digest                 string
+		value                  float64
+	}{
+		{
+			serverSeed: "server-seed", clientSeed: "client-seed", nonce: 0,
+			digest: "e4855fab63cbdebbb54a426a78d98f48a3edc2bda72642152997c70bd1ec7c33",
+			value:  float64(0xe4855fab63cbd) / (1 << rollBits),
+		},
+		{
+			serverSeed: "server-seed", clientSeed: "client-seed", nonce: 1,
+			digest: "7cf6bf5d0d1cd9de77c4d5a0b750f71167b5606e55118f21649361383da68e33",
+			value:  float64(0x7cf6bf5d0d1cd) / (1 << rollBits),
+		},
+		{
+			serverSeed: "other", clientSeed: "client-seed", nonce: 0,
+			digest: "f1b1aed6adc34125da8d15a6e038fd8ee2b77100cb7568b2b822df0ea4504845",
+			value:  float64(0xf1b1aed6adc34) / (1 << rollBits),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.digest[:8], func(t *testing.T) {
+			digest, value := Roll(tt.serverSeed, tt.clientSeed, tt.nonce)
+			if digest != tt.digest || value != tt.value {
+				t.Fatalf("Roll(%s, %s, %d) = %s, %v, want %s, %v",
+					tt.serverSeed, tt.clientSeed, tt.nonce, digest, value, tt.digest, tt.value)
+			}
+			if value < 0 || value >= 1 {
+				t.Fatalf("Roll value %v out of [0, 1)", value)
+			}
+		})
+	}
+}
+
+func TestPick(t *testing.T) {
+	weights := []int{1, 0, 3}
+
+	tests := []struct {
+		name    string
+		value   float64
+		weights []int
+		want    int
+	}{
+		{name: "start of first weight", value: 0, weights: weights, want: 0},
+		{name: "end of first weight", value: 0.24, weights: weights, want: 0},
+		{name: "zero weight is skipped", value: 0.25, weights: weights, want: 2},
+		{name: "last weight", value: 0.999, weights: weights, want: 2},
+		{name: "single weight", value: 0.5, weights: []int{5}, want: 0},
+		{name: "equal weights", value: 0.5, weights: []int{1, 1, 1, 1}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Pick(tt.value, tt.weights); got != tt.want {
+				t.Fatalf("Pick(%v, %v) = %d, want %d", tt.value, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDraw(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []int
+		n       int
+		want    []int
+	}{
+		{name: "ordered by nonce", weights: []int{1, 1, 1, 1}, n: 2, want: []int{3, 1}},
+		{name: "n capped by candidates", weights: []int{1, 0, 1}, n: 5, want: []int{2, 0}},
+		{name: "zero weights never win", weights: []int{0, 0, 7, 0}, n: 3, want: []int{2}},
+		{name: "nothing to draw", weights: []int{0, 0}, n: 1, want: []int{}},
+		{name: "zero winners", weights: []int{1, 1}, n: 0, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Draw("server-seed", "client-seed", tt.weights, tt.n)
+
+			got := make([]int, 0, len(results))
+			for nonce, r := range results {
+				got = append(got, r.Index)
+				digest, _ := Roll("server-seed", "client-seed", nonce)
+				if r.Nonce != nonce || r.Digest != digest {
+					t.Fatalf("result %d = nonce %d, digest %s, want nonce %d, digest %s", nonce, r.Nonce, r.Digest, nonce, digest)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Draw(%v, %d) = %v, want %v", tt.weights, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDrawDistinct(t *testing.T) {
+	weights := []int{5, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	results := Draw("server-seed", "client-seed", weights, len(weights))
+	if len(results) != len(weights) {
+		t.Fatalf("Draw returned %d results, want %d", len(results), len(weights))
+	}
+
+	seen := make(map[int]bool)
+	for _, r := range results {
+		if seen[r.Index] {
+			t.Fatalf("index %d drawn twice", r.Index)
+		}
+		seen[r.Index] = true
+	}
+
+	// Исходные веса не меняются: по ним розыгрыш пересчитывают для проверки
+	if !reflect.DeepEqual(weights, []int{5, 1, 1, 1, 1, 1, 1, 1, 1, 1}) {
+		t.Fatalf("Draw modified weights: %v", weights)
+	}
+}
@@ -0,0 +1,65 @@
+package fraud
+
+import (
+	"Test/config"
+	"Test/internal/model"
+	"strings"
+)
+
+const (
+	FlagSharedIP          = "shared_ip"
+	FlagSharedDevice      = "shared_device"
+	FlagRegistrationBurst = "registration_burst"
+	FlagDuplicateEmail    = "duplicate_email"
+)
+
+// Signals — всё, что известно о паре реферер/реферал на момент привязки.
+type Signals struct {
+	Referee  model.RegistrationInfo
+	Referrer model.RegistrationInfo
+	// Другие аккаунты с тем же нормализованным email, что и у реферала
+	DuplicateEmails int
+	// Рефералы того же реферера, зарегистрированные в пределах окна всплеска
+	RecentReferrals int
+}
+
+// Check возвращает список сработавших сигналов; пустой список — пару можно не задерживать.
+func Check(s Signals, cfg config.FraudConfig) []string {
+	// Не nil: pq.Array(nil) записал бы NULL в referrals.flags
+	flags := []string{}
+
+	if s.Referee.IP != "" && s.Referee.IP == s.Referrer.IP {
+		flags = append(flags, FlagSharedIP)
+	}
+	if s.Referee.DeviceID != "" && s.Referee.DeviceID == s.Referrer.DeviceID {
+		flags = append(flags, FlagSharedDevice)
+	}
+	// +1 — сам текущий реферал
+	if cfg.BurstThreshold > 0 && s.RecentReferrals+1 >= cfg.BurstThreshold {
+		flags = append(flags, FlagRegistrationBurst)
+	}
+	if s.DuplicateEmails > 0 {
+		flags = append(flags, FlagDuplicateEmail)
+	}
+
+	return flags
+}
+
+// NormalizeEmail приводит адрес к каноническому виду: нижний регистр, без +алиаса,
+// а для gmail ещё и без точек в локальной части.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+
+	local, _, _ = strings.Cut(local, "+")
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+
+	return local + "@" + domain
+}
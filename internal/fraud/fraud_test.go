@@ -0,0 +1,117 @@
+package fraud
+
+import (
+	"Test/config"
+	"Test/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "User@Example.com", want: "user@example.com"},
+		{email: "  user@example.com ", want: "user@example.com"},
+		{email: "user+promo@example.com", want: "user@example.com"},
+		{email: "first.last@example.com", want: "first.last@example.com"},
+		{email: "First.Last+1@Gmail.com", want: "firstlast@gmail.com"},
+		{email: "first.last@googlemail.com", want: "firstlast@gmail.com"},
+		{email: "+promo@example.com", want: "@example.com"},
+		{email: "not-an-email", want: "not-an-email"},
+		{email: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if got := NormalizeEmail(tt.email); got != tt.want {
+				t.Fatalf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	referrer := model.RegistrationInfo{IP: "10.0.0.1", DeviceID: "device-1"}
+	cfg := config.FraudConfig{BurstThreshold: 3}
+
+	tests := []struct {
+		name    string
+		signals Signals
+		cfg     config.FraudConfig
+		want    []string
+	}{
+		{
+			name:    "clean",
+			signals: Signals{Referee: model.RegistrationInfo{IP: "10.0.0.2", DeviceID: "device-2"}, Referrer: referrer},
+			cfg:     cfg,
+			want:    []string{},
+		},
+		{
+			name:    "shared ip",
+			signals: Signals{Referee: model.RegistrationInfo{IP: "10.0.0.1"}, Referrer: referrer},
+			cfg:     cfg,
+			want:    []string{FlagSharedIP},
+		},
+		{
+			name:    "shared device",
+			signals: Signals{Referee: model.RegistrationInfo{DeviceID: "device-1"}, Referrer: referrer},
+			cfg:     cfg,
+			want:    []string{FlagSharedDevice},
+		},
+		{
+			name:    "empty ip and device never match",
+			signals: Signals{},
+			cfg:     cfg,
+			want:    []string{},
+		},
+		{
+			name:    "below burst threshold",
+			signals: Signals{RecentReferrals: 1},
+			cfg:     cfg,
+			want:    []string{},
+		},
+		{
+			name:    "burst counts the current referral",
+			signals: Signals{RecentReferrals: 2},
+			cfg:     cfg,
+			want:    []string{FlagRegistrationBurst},
+		},
+		{
+			name:    "burst check disabled",
+			signals: Signals{RecentReferrals: 100},
+			cfg:     config.FraudConfig{},
+			want:    []string{},
+		},
+		{
+			name:    "duplicate email",
+			signals: Signals{DuplicateEmails: 1},
+			cfg:     cfg,
+			want:    []string{FlagDuplicateEmail},
+		},
+		{
+			name: "all signals",
+			signals: Signals{
+				Referee:         model.RegistrationInfo{IP: "10.0.0.1", DeviceID: "device-1"},
+				Referrer:        referrer,
+				DuplicateEmails: 2,
+				RecentReferrals: 5,
+			},
+			cfg:  cfg,
+			want: []string{FlagSharedIP, FlagSharedDevice, FlagRegistrationBurst, FlagDuplicateEmail},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.signals, tt.cfg)
+			if got == nil {
+				t.Fatal("Check returned nil, want empty slice")
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
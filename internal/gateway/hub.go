@@ -0,0 +1,298 @@
+package gateway
+
+import (
+	"Test/internal/events"
+	"Test/internal/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	TopicLeaderboard = "leaderboard:global"
+	TopicCommunity   = "community:goals"
+	userTopicPrefix  = "user:"
+	taskTopicPrefix  = "task:"
+
+	// maxTopicsPerClient ограничивает число подписок одного соединения
+	maxTopicsPerClient = 50
+	// hubBuffer — буфер подписки хаба на шину на случай всплесков событий
+	hubBuffer = 1024
+)
+
+var (
+	ErrUnknownTopic   = errors.New("unknown topic")
+	ErrForbiddenTopic = errors.New("topic is not available to this user")
+	ErrTooManyTopics  = errors.New("too many topics")
+)
+
+// Message — сообщение, отправляемое клиенту.
+type Message struct {
+	Type   string        `json:"type"`
+	Topic  string        `json:"topic,omitempty"`
+	Topics []string      `json:"topics,omitempty"`
+	Event  *events.Event `json:"event,omitempty"`
+	Error  string        `json:"error,omitempty"`
+	Code   string        `json:"code,omitempty"`
+}
+
+// Hub раздаёт события шины WebSocket-клиентам по темам. Хаб держит одну подписку
+// на шину и индекс тема → клиенты, поэтому событие сериализуется один раз на тему
+// и доставляется только подписчикам этой темы.
+type Hub struct {
+	bus    *events.Bus
+	buffer int
+
+	mu     sync.RWMutex
+	topics map[string]map[*Client]struct{}
+}
+
+func NewHub(bus *events.Bus, clientBuffer int) *Hub {
+	if clientBuffer <= 0 {
+		clientBuffer = 1
+	}
+	return &Hub{
+		bus:    bus,
+		buffer: clientBuffer,
+		topics: make(map[string]map[*Client]struct{}),
+	}
+}
+
+// Run читает шину до отмены ctx. Если хаб отстал и шина его отключила,
+// подписка восстанавливается; клиенты при этом могут пропустить события.
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		sub := h.bus.Subscribe(nil, hubBuffer)
+		h.consume(ctx, sub)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WebSocket hub fell behind the event bus, resubscribing")
+	}
+}
+
+func (h *Hub) consume(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			h.dispatch(event)
+		}
+	}
+}
+
+func (h *Hub) dispatch(event events.Event) {
+	var slow []*Client
+
+	h.mu.RLock()
+	for _, topic := range eventTopics(event) {
+		clients := h.topics[topic]
+		if len(clients) == 0 {
+			continue
+		}
+
+		// ID пользователя виден только в его личной теме: подписчики общих тем
+		// не должны узнавать, кто именно выполнил задание
+		published := event
+		if !strings.HasPrefix(topic, userTopicPrefix) {
+			published.UserID = ""
+		}
+
+		payload, err := json.Marshal(Message{Type: "event", Topic: topic, Event: &published})
+		if err != nil {
+			log.Printf("WebSocket hub marshal error: %v", err)
+			continue
+		}
+		for client := range clients {
+			if !client.enqueue(payload) {
+				slow = append(slow, client)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	for _, client := range slow {
+		client.lagged.Store(true)
+		h.Unregister(client)
+	}
+}
+
+// eventTopics возвращает темы, в которые попадает событие.
+func eventTopics(event events.Event) []string {
+	var topics []string
+	if event.UserID != "" {
+		topics = append(topics, userTopicPrefix+event.UserID)
+	}
+	switch event.Type {
+	case events.LeaderboardChanged:
+		topics = append(topics, TopicLeaderboard)
+	case events.CommunityProgress, events.CommunityReached:
+		topics = append(topics, TopicCommunity)
+	case events.TaskCompleted:
+		if task, ok := event.Data.(model.Task); ok {
+			topics = append(topics, taskTopicPrefix+task.Name)
+		}
+	}
+	return topics
+}
+
+// NewClient создаёт клиента для соединения пользователя userID.
+func (h *Hub) NewClient(userID string) *Client {
+	return &Client{
+		userID: userID,
+		send:   make(chan []byte, h.buffer),
+		done:   make(chan struct{}),
+		topics: make(map[string]struct{}),
+	}
+}
+
+// Unregister отписывает клиента от всех тем и сигнализирует его соединению закрыться.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	for topic := range c.topics {
+		h.removeLocked(topic, c)
+	}
+	c.topics = make(map[string]struct{})
+	c.closeOnce.Do(func() { close(c.done) })
+	h.mu.Unlock()
+}
+
+// Subscribe подписывает клиента на темы. Пользователь может слушать только свою
+// тему user:<id>; при ошибке в любой теме подписка не меняется.
+func (h *Hub) Subscribe(c *Client, topics []string) error {
+	for _, topic := range topics {
+		if err := validateTopic(c.userID, topic); err != nil {
+			return err
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// отключённый клиент не должен снова попасть в индекс
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+
+	added := 0
+	for _, topic := range topics {
+		if _, ok := c.topics[topic]; !ok {
+			added++
+		}
+	}
+	if len(c.topics)+added > maxTopicsPerClient {
+		return ErrTooManyTopics
+	}
+
+	for _, topic := range topics {
+		clients, ok := h.topics[topic]
+		if !ok {
+			clients = make(map[*Client]struct{})
+			h.topics[topic] = clients
+		}
+		clients[c] = struct{}{}
+		c.topics[topic] = struct{}{}
+	}
+	return nil
+}
+
+func (h *Hub) Unsubscribe(c *Client, topics []string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, topic := range topics {
+		if _, ok := c.topics[topic]; !ok {
+			continue
+		}
+		delete(c.topics, topic)
+		h.removeLocked(topic, c)
+	}
+}
+
+func (h *Hub) removeLocked(topic string, c *Client) {
+	clients := h.topics[topic]
+	delete(clients, c)
+	if len(clients) == 0 {
+		delete(h.topics, topic)
+	}
+}
+
+func validateTopic(userID, topic string) error {
+	switch {
+	case topic == TopicLeaderboard, topic == TopicCommunity:
+		return nil
+	case strings.HasPrefix(topic, userTopicPrefix):
+		if strings.TrimPrefix(topic, userTopicPrefix) != userID {
+			return ErrForbiddenTopic
+		}
+		return nil
+	case strings.HasPrefix(topic, taskTopicPrefix) && len(topic) > len(taskTopicPrefix):
+		return nil
+	default:
+		return ErrUnknownTopic
+	}
+}
+
+// Client — очередь сообщений одного WebSocket-соединения.
+type Client struct {
+	userID    string
+	send      chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+	lagged    atomic.Bool
+
+	// topics защищены мьютексом хаба
+	topics map[string]struct{}
+}
+
+// Send ставит служебное сообщение в очередь клиента; false — очередь переполнена.
+func (c *Client) Send(msg Message) bool {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("WebSocket client marshal error: %v", err)
+		return false
+	}
+	return c.enqueue(payload)
+}
+
+func (c *Client) enqueue(payload []byte) bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+	}
+
+	select {
+	case c.send <- payload:
+		return true
+	default:
+		return false
+	}
+}
+
+// Messages возвращает очередь сериализованных сообщений для записи в соединение.
+func (c *Client) Messages() <-chan []byte {
+	return c.send
+}
+
+// Done закрывается, когда клиент отключён от хаба.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Lagged сообщает, что клиент отключён из-за переполнения очереди.
+func (c *Client) Lagged() bool {
+	return c.lagged.Load()
+}
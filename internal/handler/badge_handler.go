@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CodeBadgeExists = "badge_exists"
+
+type BadgeHandler struct {
+	service *service.BadgeService
+}
+
+func NewBadgeHandler(service *service.BadgeService) *BadgeHandler {
+	return &BadgeHandler{service: service}
+}
+
+func (h *BadgeHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *BadgeHandler) ListBadges(c *gin.Context) {
+	badges, err := h.service.ListBadges(c.Request.Context())
+	if err != nil {
+		log.Printf("ListBadges error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list badges")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"badges": badges})
+}
+
+func (h *BadgeHandler) CreateBadge(c *gin.Context) {
+	var req struct {
+		ID          string          `json:"id" binding:"required"`
+		Name        string          `json:"name" binding:"required"`
+		Description string          `json:"description"`
+		Rule        model.BadgeRule `json:"rule" binding:"required"`
+		Threshold   int             `json:"threshold" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	badge := &model.Badge{
+		ID:          req.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Rule:        req.Rule,
+		Threshold:   req.Threshold,
+	}
+	if err := h.service.CreateBadge(c.Request.Context(), badge); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidBadge):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrBadgeExists):
+			h.sendError(c, http.StatusConflict, CodeBadgeExists, err.Error())
+		default:
+			log.Printf("CreateBadge error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create badge")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, badge)
+}
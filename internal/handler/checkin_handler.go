@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeAlreadyCheckedIn = "already_checked_in"
+	CodeFreezeLimit      = "freeze_limit_reached"
+	CodeFreezesDisabled  = "freezes_disabled"
+)
+
+type CheckinHandler struct {
+	service *service.CheckinService
+}
+
+func NewCheckinHandler(service *service.CheckinService) *CheckinHandler {
+	return &CheckinHandler{service: service}
+}
+
+func (h *CheckinHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// CheckIn — ежедневная отметка; тело необязательно: {"timezone": "Europe/Moscow"} учитывается только в первый раз.
+func (h *CheckinHandler) CheckIn(c *gin.Context) {
+	var req struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	checkin, err := h.service.CheckIn(c.Request.Context(), c.Param("id"), req.Timezone)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTimezone):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrAlreadyCheckedIn):
+			h.sendError(c, http.StatusConflict, CodeAlreadyCheckedIn, err.Error())
+		default:
+			log.Printf("CheckIn error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to check in")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, checkin)
+}
+
+func (h *CheckinHandler) BuyFreeze(c *gin.Context) {
+	purchase, err := h.service.BuyFreeze(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrFreezesDisabled):
+			h.sendError(c, http.StatusConflict, CodeFreezesDisabled, err.Error())
+		case errors.Is(err, repository.ErrFreezeLimit):
+			h.sendError(c, http.StatusConflict, CodeFreezeLimit, err.Error())
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			h.sendError(c, http.StatusConflict, CodeInsufficientPoints, err.Error())
+		default:
+			log.Printf("BuyFreeze error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to buy streak freeze")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchase)
+}
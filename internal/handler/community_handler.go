@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CodeCommunityGoalNotFound = "community_goal_not_found"
+
+type CommunityHandler struct {
+	service *service.CommunityService
+}
+
+func NewCommunityHandler(service *service.CommunityService) *CommunityHandler {
+	return &CommunityHandler{service: service}
+}
+
+func (h *CommunityHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *CommunityHandler) ListGoals(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListGoals(c.Request.Context(), limit, offset)
+	if err != nil {
+		log.Printf("ListCommunityGoals error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list community goals")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *CommunityHandler) GetGoal(c *gin.Context) {
+	goalID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid goal id")
+		return
+	}
+
+	goal, err := h.service.GetGoal(c.Request.Context(), goalID)
+	if err != nil {
+		if errors.Is(err, repository.ErrCommunityGoalNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeCommunityGoalNotFound, err.Error())
+		} else {
+			log.Printf("GetCommunityGoal error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get community goal")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, goal)
+}
+
+// CreateGoal — админский эндпоинт: общая цель по числу выполнений задания всеми пользователями.
+func (h *CommunityHandler) CreateGoal(c *gin.Context) {
+	var req struct {
+		Title        string    `json:"title" binding:"required"`
+		Task         string    `json:"task" binding:"required"`
+		Goal         int       `json:"goal" binding:"required"`
+		RewardPoints int       `json:"reward_points" binding:"required"`
+		StartsAt     time.Time `json:"starts_at"`
+		EndsAt       time.Time `json:"ends_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	goal := &model.CommunityGoal{
+		Title:        req.Title,
+		Task:         req.Task,
+		Goal:         req.Goal,
+		RewardPoints: req.RewardPoints,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+	}
+	if err := h.service.CreateGoal(c.Request.Context(), goal); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCommunityGoal):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrTaskNotFound):
+			h.sendError(c, http.StatusNotFound, CodeTaskNotFound, err.Error())
+		default:
+			log.Printf("CreateCommunityGoal error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create community goal")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, goal)
+}
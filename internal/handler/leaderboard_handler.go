@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/service"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLeaderboardLimit  = 10
+	defaultLeaderboardAround = 2
+	maxLeaderboardAround     = 10
+)
+
+type LeaderboardHandler struct {
+	service *service.LeaderboardService
+}
+
+func NewLeaderboardHandler(service *service.LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{service: service}
+}
+
+func (h *LeaderboardHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultLeaderboardLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	around := defaultLeaderboardAround
+	if raw := c.Query("around"); raw != "" {
+		around, err = strconv.Atoi(raw)
+		if err != nil || around < 0 || around > maxLeaderboardAround {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest,
+				fmt.Sprintf("around must be between 0 and %d", maxLeaderboardAround))
+			return
+		}
+	}
+
+	period := model.LeaderboardPeriod(c.DefaultQuery("period", string(model.PeriodAll)))
+	scope := model.LeaderboardScope(c.DefaultQuery("scope", string(model.ScopeGlobal)))
+
+	currency := model.Currency(c.DefaultQuery("currency", string(model.CurrencyXP)))
+
+	page, err := h.service.GetLeaderboard(c.Request.Context(), c.GetString("user_id"),
+		currency, period, scope, c.Query("cohort"), limit, offset, around)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPeriod) || errors.Is(err, service.ErrInvalidScope) ||
+			errors.Is(err, service.ErrInvalidCohort) || errors.Is(err, service.ErrInvalidCurrency) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("GetLeaderboard error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get leaderboard")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *LeaderboardHandler) CheckIndex(c *gin.Context) {
+	check, err := h.service.CheckIndex(c.Request.Context())
+	if err != nil {
+		log.Printf("CheckIndex error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to check ranking index")
+		return
+	}
+
+	c.JSON(http.StatusOK, check)
+}
+
+func (h *LeaderboardHandler) SetVisibility(c *gin.Context) {
+	var req struct {
+		Visibility model.LeaderboardVisibility `json:"visibility" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	if err := h.service.SetVisibility(c.Request.Context(), c.Param("id"), req.Visibility); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVisibility):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		default:
+			log.Printf("SetVisibility error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to set leaderboard visibility")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "visibility": req.Visibility})
+}
+
+func (h *LeaderboardHandler) ListHidden(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListHidden(c.Request.Context(), limit, offset)
+	if err != nil {
+		log.Printf("ListHidden error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list hidden users")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
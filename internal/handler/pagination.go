@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	defaultStatsRange = 30 * 24 * time.Hour
+)
+
+// parsePagination читает limit/offset из query-параметров.
+func parsePagination(c *gin.Context, defaultLimit int) (limit, offset int, err error) {
+	limit = defaultLimit
+	if raw := c.Query("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+// parseTimeRange читает from/to (RFC3339 или YYYY-MM-DD); по умолчанию — последние 30 дней.
+// Дата без времени в to включает весь день.
+func parseTimeRange(c *gin.Context) (from, to time.Time, err error) {
+	to = time.Now()
+	if raw := c.Query("to"); raw != "" {
+		to, err = parseTimeParam(raw, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+
+	from = to.Add(-defaultStatsRange)
+	if raw := c.Query("from"); raw != "" {
+		from, err = parseTimeParam(raw, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	return from, to, nil
+}
+
+func parseTimeParam(raw string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC3339 or YYYY-MM-DD")
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
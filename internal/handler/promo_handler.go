@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodePromoNotFound      = "promo_not_found"
+	CodePromoNotActive     = "promo_not_active"
+	CodePromoExhausted     = "promo_exhausted"
+	CodePromoUserLimit     = "promo_user_limit"
+	CodePromoCodeExists    = "promo_code_exists"
+	CodePromoBatchNotFound = "promo_batch_not_found"
+)
+
+type PromoHandler struct {
+	service *service.PromoService
+}
+
+func NewPromoHandler(service *service.PromoService) *PromoHandler {
+	return &PromoHandler{service: service}
+}
+
+func (h *PromoHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *PromoHandler) Redeem(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		h.sendError(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	redemption, err := h.service.Redeem(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPromoNotFound):
+			h.sendError(c, http.StatusNotFound, CodePromoNotFound, err.Error())
+		case errors.Is(err, repository.ErrPromoNotActive):
+			h.sendError(c, http.StatusConflict, CodePromoNotActive, err.Error())
+		case errors.Is(err, repository.ErrPromoExhausted):
+			h.sendError(c, http.StatusConflict, CodePromoExhausted, err.Error())
+		case errors.Is(err, repository.ErrPromoUserLimit):
+			h.sendError(c, http.StatusConflict, CodePromoUserLimit, err.Error())
+		default:
+			log.Printf("RedeemPromo error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to redeem promo code")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, redemption)
+}
+
+// CreateBatch — админский эндпоинт: партия из одного заданного кода (code)
+// или из count сгенерированных кодов с необязательным префиксом.
+func (h *PromoHandler) CreateBatch(c *gin.Context) {
+	var req struct {
+		Name           string     `json:"name" binding:"required"`
+		Points         int        `json:"points" binding:"required"`
+		MaxUses        *int       `json:"max_uses"`
+		MaxUsesPerUser int        `json:"max_uses_per_user"`
+		ValidFrom      *time.Time `json:"valid_from"`
+		ValidUntil     *time.Time `json:"valid_until"`
+		Code           string     `json:"code"`
+		Prefix         string     `json:"prefix"`
+		Count          int        `json:"count"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	batch := &model.PromoBatch{
+		Name:           req.Name,
+		Points:         req.Points,
+		MaxUses:        req.MaxUses,
+		MaxUsesPerUser: req.MaxUsesPerUser,
+		ValidFrom:      req.ValidFrom,
+		ValidUntil:     req.ValidUntil,
+	}
+	if err := h.service.CreateBatch(c.Request.Context(), batch, req.Code, req.Prefix, req.Count); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidPromoBatch), errors.Is(err, service.ErrInvalidPromoCode):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrPromoCodeExists):
+			h.sendError(c, http.StatusConflict, CodePromoCodeExists, err.Error())
+		default:
+			log.Printf("CreatePromoBatch error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create promo batch")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, batch)
+}
+
+func (h *PromoHandler) ListBatches(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	batches, total, err := h.service.ListBatches(c.Request.Context(), limit, offset)
+	if err != nil {
+		log.Printf("ListPromoBatches error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list promo batches")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batches": batches,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// ExportCodes отдаёт коды партии в CSV: code,uses.
+func (h *PromoHandler) ExportCodes(c *gin.Context) {
+	batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid batch id")
+		return
+	}
+
+	codes, err := h.service.ListCodes(c.Request.Context(), batchID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPromoBatchNotFound) {
+			h.sendError(c, http.StatusNotFound, CodePromoBatchNotFound, err.Error())
+		} else {
+			log.Printf("ExportPromoCodes error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to export promo codes")
+		}
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="promo-batch-%d.csv"`, batchID))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"code", "uses"})
+	for _, code := range codes {
+		w.Write([]string{code.Code, strconv.Itoa(code.Uses)})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("ExportPromoCodes write error: %v", err)
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeRaffleNotFound    = "raffle_not_found"
+	CodeRaffleClosed      = "raffle_closed"
+	CodeRaffleNotOpen     = "raffle_not_open"
+	CodeRaffleTicketLimit = "raffle_ticket_limit"
+)
+
+type RaffleHandler struct {
+	service *service.RaffleService
+}
+
+func NewRaffleHandler(service *service.RaffleService) *RaffleHandler {
+	return &RaffleHandler{service: service}
+}
+
+func (h *RaffleHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *RaffleHandler) raffleID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid raffle id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *RaffleHandler) ListRaffles(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	status := model.RaffleStatus(c.Query("status"))
+	page, err := h.service.ListRaffles(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRaffleStatus) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("ListRaffles error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list raffles")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *RaffleHandler) GetRaffle(c *gin.Context) {
+	raffleID, ok := h.raffleID(c)
+	if !ok {
+		return
+	}
+
+	raffle, err := h.service.GetRaffle(c.Request.Context(), raffleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRaffleNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeRaffleNotFound, err.Error())
+		} else {
+			log.Printf("GetRaffle error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get raffle")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, raffle)
+}
+
+func (h *RaffleHandler) ListEntries(c *gin.Context) {
+	raffleID, ok := h.raffleID(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.service.ListEntries(c.Request.Context(), raffleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrRaffleNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeRaffleNotFound, err.Error())
+		} else {
+			log.Printf("ListRaffleEntries error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list raffle entries")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": entries})
+}
+
+func (h *RaffleHandler) BuyTickets(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		h.sendError(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	raffleID, ok := h.raffleID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Quantity int `json:"quantity" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	purchase, err := h.service.BuyTickets(c.Request.Context(), raffleID, userID, req.Quantity)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTicketAmount):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrRaffleNotFound):
+			h.sendError(c, http.StatusNotFound, CodeRaffleNotFound, err.Error())
+		case errors.Is(err, repository.ErrRaffleClosed):
+			h.sendError(c, http.StatusConflict, CodeRaffleClosed, err.Error())
+		case errors.Is(err, repository.ErrRaffleTicketLimit):
+			h.sendError(c, http.StatusConflict, CodeRaffleTicketLimit, err.Error())
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			h.sendError(c, http.StatusConflict, CodeInsufficientPoints, err.Error())
+		default:
+			log.Printf("BuyRaffleTickets error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to buy raffle tickets")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, purchase)
+}
+
+func (h *RaffleHandler) CreateRaffle(c *gin.Context) {
+	var req struct {
+		Title             string    `json:"title" binding:"required"`
+		Description       string    `json:"description"`
+		Prize             string    `json:"prize" binding:"required"`
+		PrizePoints       int       `json:"prize_points"`
+		TicketPrice       int       `json:"ticket_price" binding:"required"`
+		MaxTicketsPerUser int       `json:"max_tickets_per_user"`
+		Winners           int       `json:"winners"`
+		DrawAt            time.Time `json:"draw_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+	if req.Winners == 0 {
+		req.Winners = 1
+	}
+
+	raffle := &model.Raffle{
+		Title:             req.Title,
+		Description:       req.Description,
+		Prize:             req.Prize,
+		PrizePoints:       req.PrizePoints,
+		TicketPrice:       req.TicketPrice,
+		MaxTicketsPerUser: req.MaxTicketsPerUser,
+		Winners:           req.Winners,
+		DrawAt:            req.DrawAt,
+	}
+	if err := h.service.CreateRaffle(c.Request.Context(), raffle); err != nil {
+		if errors.Is(err, service.ErrInvalidRaffle) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("CreateRaffle error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create raffle")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, raffle)
+}
+
+func (h *RaffleHandler) CancelRaffle(c *gin.Context) {
+	raffleID, ok := h.raffleID(c)
+	if !ok {
+		return
+	}
+
+	raffle, err := h.service.CancelRaffle(c.Request.Context(), raffleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRaffleNotFound):
+			h.sendError(c, http.StatusNotFound, CodeRaffleNotFound, err.Error())
+		case errors.Is(err, repository.ErrRaffleNotOpen):
+			h.sendError(c, http.StatusConflict, CodeRaffleNotOpen, err.Error())
+		default:
+			log.Printf("CancelRaffle error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to cancel raffle")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, raffle)
+}
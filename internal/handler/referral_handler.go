@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeReferralNotFound   = "referral_not_found"
+	CodeReferrerNotFound   = "referrer_not_found"
+	CodeReferrerAlreadySet = "referrer_already_set"
+	CodeReferralCycle      = "referral_cycle"
+)
+
+type ReferralHandler struct {
+	service *service.ReferralService
+}
+
+func NewReferralHandler(service *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{service: service}
+}
+
+func (h *ReferralHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *ReferralHandler) SetReferrer(c *gin.Context) {
+	userID := c.Param("id")
+
+	var req struct {
+		ReferrerID string `json:"referrer_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	flags, err := h.service.SetReferrer(c.Request.Context(), userID, req.ReferrerID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrSelfReferral):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrReferralCycle):
+			h.sendError(c, http.StatusBadRequest, CodeReferralCycle, err.Error())
+		case errors.Is(err, repository.ErrReferrerNotFound):
+			h.sendError(c, http.StatusNotFound, CodeReferrerNotFound, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		case errors.Is(err, repository.ErrReferrerAlreadySet):
+			h.sendError(c, http.StatusConflict, CodeReferrerAlreadySet, err.Error())
+		default:
+			log.Printf("SetReferrer error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to set referrer")
+		}
+		return
+	}
+
+	rewardStatus := model.ReferralPending
+	if len(flags) > 0 {
+		rewardStatus = model.ReferralReview
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success", "reward_status": rewardStatus})
+}
+
+func (h *ReferralHandler) GetReferralTree(c *gin.Context) {
+	userID := c.Param("id")
+
+	tree, err := h.service.GetReferralTree(c.Request.Context(), userID)
+	if err != nil {
+		log.Printf("GetReferralTree error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get referral tree")
+		return
+	}
+
+	c.JSON(http.StatusOK, tree)
+}
+
+func (h *ReferralHandler) ListInvited(c *gin.Context) {
+	userID := c.Param("id")
+
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListInvited(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		log.Printf("ListInvited error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list referrals")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *ReferralHandler) ListFlagged(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListFlagged(c.Request.Context(), limit, offset)
+	if err != nil {
+		log.Printf("ListFlagged error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list flagged referrals")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *ReferralHandler) ResolveFlagged(c *gin.Context) {
+	refereeID := c.Param("referee_id")
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=approve reject"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "action must be approve or reject")
+		return
+	}
+
+	err := h.service.ResolveFlagged(c.Request.Context(), refereeID, req.Action == "approve", req.Note)
+	if err != nil {
+		if errors.Is(err, repository.ErrReferralNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeReferralNotFound, "flagged referral not found")
+		} else {
+			log.Printf("ResolveFlagged error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to resolve referral")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// TrackInvite фиксирует переход по пригласительной ссылке и перенаправляет на регистрацию.
+func (h *ReferralHandler) TrackInvite(c *gin.Context) {
+	referrerID := c.Param("referrer_id")
+
+	if err := h.service.RecordClick(c.Request.Context(), referrerID, c.ClientIP(), c.Request.UserAgent()); err != nil {
+		log.Printf("TrackInvite error: %v", err)
+	}
+
+	c.Redirect(http.StatusFound, h.service.InviteRedirectURL(referrerID))
+}
+
+func (h *ReferralHandler) GetStatsReport(c *gin.Context) {
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	report, err := h.service.GetStatsReport(c.Request.Context(), from, to, limit, offset)
+	if err != nil {
+		log.Printf("GetStatsReport error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get referral stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *ReferralHandler) GetUserStats(c *gin.Context) {
+	userID := c.Param("id")
+
+	from, to, err := parseTimeRange(c)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	report, err := h.service.GetUserStats(c.Request.Context(), userID, from, to)
+	if err != nil {
+		log.Printf("GetUserStats error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get referral stats")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeRewardNotFound     = "reward_not_found"
+	CodeRewardUnavailable  = "reward_unavailable"
+	CodeOutOfStock         = "out_of_stock"
+	CodeRedeemLimit        = "redeem_limit_reached"
+	CodeInsufficientPoints = "insufficient_points"
+	CodeOrderNotFound      = "order_not_found"
+	CodeOrderNotPending    = "order_not_pending"
+)
+
+type RewardHandler struct {
+	service *service.RewardService
+}
+
+func NewRewardHandler(service *service.RewardService) *RewardHandler {
+	return &RewardHandler{service: service}
+}
+
+func (h *RewardHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+type rewardRequest struct {
+	Name         string `json:"name" binding:"required"`
+	Description  string `json:"description"`
+	Cost         int    `json:"cost" binding:"required"`
+	Stock        *int   `json:"stock"`
+	PerUserLimit *int   `json:"per_user_limit"`
+	Active       *bool  `json:"active"`
+}
+
+func (r rewardRequest) toReward() *model.Reward {
+	reward := &model.Reward{
+		Name:         r.Name,
+		Description:  r.Description,
+		Cost:         r.Cost,
+		Stock:        r.Stock,
+		PerUserLimit: r.PerUserLimit,
+		Active:       true,
+	}
+	if r.Active != nil {
+		reward.Active = *r.Active
+	}
+	return reward
+}
+
+func (h *RewardHandler) ListCatalog(c *gin.Context) {
+	rewards, err := h.service.ListCatalog(c.Request.Context())
+	if err != nil {
+		log.Printf("ListCatalog error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list rewards")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rewards": rewards})
+}
+
+func (h *RewardHandler) Redeem(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		h.sendError(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	rewardID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid reward id")
+		return
+	}
+
+	redemption, err := h.service.Redeem(c.Request.Context(), userID, rewardID)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrRewardNotFound):
+			h.sendError(c, http.StatusNotFound, CodeRewardNotFound, "reward not found")
+		case errors.Is(err, repository.ErrRewardUnavailable):
+			h.sendError(c, http.StatusConflict, CodeRewardUnavailable, err.Error())
+		case errors.Is(err, repository.ErrOutOfStock):
+			h.sendError(c, http.StatusConflict, CodeOutOfStock, err.Error())
+		case errors.Is(err, repository.ErrRedeemLimit):
+			h.sendError(c, http.StatusConflict, CodeRedeemLimit, err.Error())
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			h.sendError(c, http.StatusConflict, CodeInsufficientPoints, err.Error())
+		default:
+			log.Printf("Redeem error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to redeem reward")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, redemption)
+}
+
+func (h *RewardHandler) ListUserOrders(c *gin.Context) {
+	h.listOrders(c, c.Param("id"))
+}
+
+func (h *RewardHandler) ListOrders(c *gin.Context) {
+	h.listOrders(c, "")
+}
+
+func (h *RewardHandler) listOrders(c *gin.Context, userID string) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	status := model.OrderStatus(c.Query("status"))
+	page, err := h.service.ListOrders(c.Request.Context(), userID, status, limit, offset)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidOrderStatus) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("ListOrders error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list orders")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *RewardHandler) ListRewards(c *gin.Context) {
+	rewards, err := h.service.ListAll(c.Request.Context())
+	if err != nil {
+		log.Printf("ListRewards error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list rewards")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rewards": rewards})
+}
+
+func (h *RewardHandler) CreateReward(c *gin.Context) {
+	var req rewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	reward := req.toReward()
+	if err := h.service.CreateReward(c.Request.Context(), reward); err != nil {
+		if errors.Is(err, service.ErrInvalidReward) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("CreateReward error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create reward")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, reward)
+}
+
+func (h *RewardHandler) UpdateReward(c *gin.Context) {
+	rewardID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid reward id")
+		return
+	}
+
+	var req rewardRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	reward := req.toReward()
+	reward.ID = rewardID
+	if err := h.service.UpdateReward(c.Request.Context(), reward); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidReward):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrRewardNotFound):
+			h.sendError(c, http.StatusNotFound, CodeRewardNotFound, "reward not found")
+		default:
+			log.Printf("UpdateReward error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to update reward")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, reward)
+}
+
+// ResolveOrder — админский эндпоинт: action fulfill выполняет заказ,
+// cancel — отменяет его с возвратом очков.
+func (h *RewardHandler) ResolveOrder(c *gin.Context) {
+	orderID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid order id")
+		return
+	}
+
+	var req struct {
+		Action string `json:"action" binding:"required,oneof=fulfill cancel"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "action must be fulfill or cancel")
+		return
+	}
+
+	if req.Action == "fulfill" {
+		err = h.service.FulfillOrder(c.Request.Context(), orderID, req.Note)
+	} else {
+		err = h.service.CancelOrder(c.Request.Context(), orderID, req.Note)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			h.sendError(c, http.StatusNotFound, CodeOrderNotFound, "order not found")
+		case errors.Is(err, repository.ErrOrderNotPending):
+			h.sendError(c, http.StatusConflict, CodeOrderNotPending, err.Error())
+		default:
+			log.Printf("ResolveOrder error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to resolve order")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CodeSeasonNotFound = "season_not_found"
+
+type SeasonHandler struct {
+	service *service.SeasonService
+}
+
+func NewSeasonHandler(service *service.SeasonService) *SeasonHandler {
+	return &SeasonHandler{service: service}
+}
+
+func (h *SeasonHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *SeasonHandler) CreateSeason(c *gin.Context) {
+	var req struct {
+		Name     string               `json:"name" binding:"required"`
+		StartsAt time.Time            `json:"starts_at" binding:"required"`
+		EndsAt   time.Time            `json:"ends_at" binding:"required"`
+		Rewards  []model.SeasonReward `json:"rewards"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	season := &model.Season{
+		Name:     req.Name,
+		StartsAt: req.StartsAt,
+		EndsAt:   req.EndsAt,
+		Rewards:  req.Rewards,
+	}
+	if err := h.service.CreateSeason(c.Request.Context(), season); err != nil {
+		if errors.Is(err, service.ErrInvalidSeason) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("CreateSeason error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to create season")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, season)
+}
+
+func (h *SeasonHandler) ListSeasons(c *gin.Context) {
+	seasons, err := h.service.ListSeasons(c.Request.Context())
+	if err != nil {
+		log.Printf("ListSeasons error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list seasons")
+		return
+	}
+
+	c.JSON(http.StatusOK, seasons)
+}
+
+func (h *SeasonHandler) GetStandings(c *gin.Context) {
+	seasonID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid season id")
+		return
+	}
+
+	limit, offset, err := parsePagination(c, defaultLeaderboardLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	standings, err := h.service.GetStandings(c.Request.Context(), seasonID, limit, offset)
+	if err != nil {
+		if errors.Is(err, repository.ErrSeasonNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeSeasonNotFound, "season not found")
+		} else {
+			log.Printf("GetStandings error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get season standings")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, standings)
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"Test/internal/events"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StreamHandler struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+	buffer    int
+}
+
+func NewStreamHandler(bus *events.Bus, heartbeat time.Duration, buffer int) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &StreamHandler{bus: bus, heartbeat: heartbeat, buffer: buffer}
+}
+
+func (h *StreamHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// Stream отдаёт события пользователя и общие события рейтинга по SSE.
+// После переподключения клиент передаёт Last-Event-ID и получает пропущенное;
+// если история уже вытеснена, приходит событие reset.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		h.sendError(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	lastID, resume, err := lastEventID(c)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid Last-Event-ID")
+		return
+	}
+
+	filter := func(e events.Event) bool {
+		return e.UserID == "" || e.UserID == userID
+	}
+
+	// Подписываемся до чтения истории, чтобы не потерять события между ними
+	sub := h.bus.Subscribe(filter, h.buffer)
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if resume {
+		missed, ok := h.bus.Since(lastID, filter)
+		if !ok {
+			fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+		}
+		for _, event := range missed {
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case event, ok := <-sub.C():
+			if !ok {
+				// Клиент не успевал читать: разрываем поток, он переподключится с Last-Event-ID
+				log.Printf("Stream for user %s dropped: client is too slow", userID)
+				return
+			}
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(c, event); err != nil {
+				return
+			}
+			lastID = event.ID
+			c.Writer.Flush()
+		}
+	}
+}
+
+func writeEvent(c *gin.Context, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
+
+// lastEventID читает заголовок Last-Event-ID или параметр last_event_id
+// (EventSource в браузере не позволяет задать заголовок при первом подключении).
+func lastEventID(c *gin.Context) (uint64, bool, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
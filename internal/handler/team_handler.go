@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeTeamNotFound  = "team_not_found"
+	CodeTeamNameTaken = "team_name_taken"
+	CodeAlreadyInTeam = "already_in_team"
+	CodeInviteInvalid = "invite_not_found"
+	CodeTeamFull      = "team_full"
+	CodeNotTeamMember = "not_team_member"
+	CodeNotCaptain    = "not_team_captain"
+	CodeTaskNotFound  = "task_not_found"
+)
+
+type TeamHandler struct {
+	service *service.TeamService
+}
+
+func NewTeamHandler(service *service.TeamService) *TeamHandler {
+	return &TeamHandler{service: service}
+}
+
+func (h *TeamHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// handleError отвечает на ошибки команд; op попадает в лог и текст ошибки 500.
+func (h *TeamHandler) handleError(c *gin.Context, err error, op string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTeam), errors.Is(err, service.ErrInvalidTeamChallenge):
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+	case errors.Is(err, repository.ErrTeamNotFound):
+		h.sendError(c, http.StatusNotFound, CodeTeamNotFound, err.Error())
+	case errors.Is(err, repository.ErrInviteNotFound):
+		h.sendError(c, http.StatusNotFound, CodeInviteInvalid, err.Error())
+	case errors.Is(err, repository.ErrTaskNotFound):
+		h.sendError(c, http.StatusNotFound, CodeTaskNotFound, err.Error())
+	case errors.Is(err, repository.ErrTeamNameTaken):
+		h.sendError(c, http.StatusConflict, CodeTeamNameTaken, err.Error())
+	case errors.Is(err, repository.ErrAlreadyInTeam):
+		h.sendError(c, http.StatusConflict, CodeAlreadyInTeam, err.Error())
+	case errors.Is(err, repository.ErrTeamFull):
+		h.sendError(c, http.StatusConflict, CodeTeamFull, err.Error())
+	case errors.Is(err, repository.ErrNotTeamMember):
+		h.sendError(c, http.StatusConflict, CodeNotTeamMember, err.Error())
+	case errors.Is(err, repository.ErrNotTeamCaptain):
+		h.sendError(c, http.StatusForbidden, CodeNotCaptain, err.Error())
+	default:
+		log.Printf("%s error: %v", op, err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to "+op)
+	}
+}
+
+func (h *TeamHandler) teamID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid team id")
+		return 0, false
+	}
+	return id, true
+}
+
+func (h *TeamHandler) CreateTeam(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	team, err := h.service.CreateTeam(c.Request.Context(), c.GetString("user_id"), req.Name)
+	if err != nil {
+		h.handleError(c, err, "create team")
+		return
+	}
+
+	c.JSON(http.StatusCreated, team)
+}
+
+func (h *TeamHandler) GetTeam(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	team, err := h.service.GetTeam(c.Request.Context(), teamID, c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err, "get team")
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) GetUserTeam(c *gin.Context) {
+	team, err := h.service.GetUserTeam(c.Request.Context(), c.Param("id"), c.GetString("user_id"))
+	if err != nil {
+		h.handleError(c, err, "get user team")
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) JoinTeam(c *gin.Context) {
+	var req struct {
+		InviteCode string `json:"invite_code" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	team, err := h.service.JoinTeam(c.Request.Context(), c.GetString("user_id"), req.InviteCode)
+	if err != nil {
+		h.handleError(c, err, "join team")
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+func (h *TeamHandler) LeaveTeam(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.LeaveTeam(c.Request.Context(), c.GetString("user_id"), teamID); err != nil {
+		h.handleError(c, err, "leave team")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TeamHandler) RemoveMember(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.RemoveMember(c.Request.Context(), c.GetString("user_id"), teamID, c.Param("user_id"))
+	if err != nil {
+		h.handleError(c, err, "remove team member")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TeamHandler) SetCaptain(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		UserID string `json:"user_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	if err := h.service.SetCaptain(c.Request.Context(), c.GetString("user_id"), teamID, req.UserID); err != nil {
+		h.handleError(c, err, "set team captain")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *TeamHandler) RotateInviteCode(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	code, err := h.service.RotateInviteCode(c.Request.Context(), c.GetString("user_id"), teamID)
+	if err != nil {
+		h.handleError(c, err, "rotate invite code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invite_code": code})
+}
+
+func (h *TeamHandler) GetLeaderboard(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.GetLeaderboard(c.Request.Context(), limit, offset)
+	if err != nil {
+		h.handleError(c, err, "get team leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *TeamHandler) ListChallenges(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	challenges, err := h.service.ListChallenges(c.Request.Context(), teamID)
+	if err != nil {
+		h.handleError(c, err, "list team challenges")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"challenges": challenges})
+}
+
+// CreateChallenge — админский эндпоинт: общая цель команды по выполненным заданиям.
+func (h *TeamHandler) CreateChallenge(c *gin.Context) {
+	teamID, ok := h.teamID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Title        string    `json:"title" binding:"required"`
+		Task         string    `json:"task"`
+		Goal         int       `json:"goal" binding:"required"`
+		RewardPoints int       `json:"reward_points"`
+		StartsAt     time.Time `json:"starts_at"`
+		EndsAt       time.Time `json:"ends_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	challenge := &model.TeamChallenge{
+		TeamID:       teamID,
+		Title:        req.Title,
+		Task:         req.Task,
+		Goal:         req.Goal,
+		RewardPoints: req.RewardPoints,
+		StartsAt:     req.StartsAt,
+		EndsAt:       req.EndsAt,
+	}
+	if err := h.service.CreateChallenge(c.Request.Context(), challenge); err != nil {
+		h.handleError(c, err, "create team challenge")
+		return
+	}
+
+	c.JSON(http.StatusCreated, challenge)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CodeRecipientNotFound   = "recipient_not_found"
+	CodeTransferLimit       = "transfer_limit_exceeded"
+	CodeIdempotencyConflict = "idempotency_conflict"
+)
+
+type TransferHandler struct {
+	service *service.TransferService
+}
+
+func NewTransferHandler(service *service.TransferService) *TransferHandler {
+	return &TransferHandler{service: service}
+}
+
+func (h *TransferHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// CreateTransfer переводит очки; ключ идемпотентности берётся из заголовка
+// Idempotency-Key или поля idempotency_key.
+func (h *TransferHandler) CreateTransfer(c *gin.Context) {
+	var req struct {
+		RecipientID    string `json:"recipient_id" binding:"required"`
+		Amount         int    `json:"amount" binding:"required"`
+		Memo           string `json:"memo"`
+		IdempotencyKey string `json:"idempotency_key"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	key := c.GetHeader("Idempotency-Key")
+	if key == "" {
+		key = req.IdempotencyKey
+	}
+
+	result, err := h.service.Transfer(c.Request.Context(), c.Param("id"), req.RecipientID, req.Amount, req.Memo, key)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidTransfer):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, repository.ErrRecipientNotFound):
+			h.sendError(c, http.StatusNotFound, CodeRecipientNotFound, err.Error())
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			h.sendError(c, http.StatusConflict, CodeInsufficientPoints, err.Error())
+		case errors.Is(err, repository.ErrTransferLimit):
+			h.sendError(c, http.StatusConflict, CodeTransferLimit, err.Error())
+		case errors.Is(err, repository.ErrIdempotencyConflict):
+			h.sendError(c, http.StatusConflict, CodeIdempotencyConflict, err.Error())
+		default:
+			log.Printf("CreateTransfer error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to transfer points")
+		}
+		return
+	}
+
+	status := http.StatusCreated
+	if result.Replayed {
+		status = http.StatusOK
+	}
+	c.JSON(status, result)
+}
+
+func (h *TransferHandler) ListTransfers(c *gin.Context) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListTransfers(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		log.Printf("ListTransfers error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list transfers")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"Test/internal/model"
+	"Test/internal/repository"
 	"Test/internal/service"
 	"database/sql"
 	"errors"
@@ -34,12 +36,16 @@ func (h *UserHandler) sendError(c *gin.Context, status int, code, message string
 
 func (h *UserHandler) GetUserStatus(c *gin.Context) {
 	userID := c.Param("id")
+	currency := model.Currency(c.DefaultQuery("currency", string(model.CurrencyPoints)))
 
-	status, err := h.service.GetUserStatus(c.Request.Context(), userID)
+	status, err := h.service.GetUserStatus(c.Request.Context(), userID, currency)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		switch {
+		case errors.Is(err, service.ErrInvalidCurrency):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
 			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
-		} else {
+		default:
 			log.Printf("GetUserStatus error: %v", err)
 			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get user status")
 		}
@@ -49,6 +55,21 @@ func (h *UserHandler) GetUserStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	profile, err := h.service.GetProfile(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		} else {
+			log.Printf("GetProfile error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get profile")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
 func (h *UserHandler) CompleteTask(c *gin.Context) {
 	userID := c.Param("id")
 
@@ -61,41 +82,110 @@ func (h *UserHandler) CompleteTask(c *gin.Context) {
 	}
 
 	if err := h.service.CompleteTask(c.Request.Context(), userID, req.TaskName); err != nil {
-		log.Printf("CompleteTask error: %v", err)
-		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to complete task")
+		if errors.Is(err, repository.ErrTaskNotFound) {
+			h.sendError(c, http.StatusNotFound, CodeTaskNotFound, err.Error())
+		} else {
+			log.Printf("CompleteTask error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to complete task")
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
-func (h *UserHandler) SetReferrer(c *gin.Context) {
-	userID := c.Param("id")
+func (h *UserHandler) ListFriends(c *gin.Context) {
+	friends, err := h.service.ListFriends(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("ListFriends error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list friends")
+		return
+	}
 
+	c.JSON(http.StatusOK, gin.H{"friends": friends})
+}
+
+func (h *UserHandler) AddFriend(c *gin.Context) {
 	var req struct {
-		ReferrerID string `json:"referrer_id" binding:"required"`
+		FriendID string `json:"friend_id" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
 		return
 	}
 
-	if err := h.service.SetReferrer(c.Request.Context(), userID, req.ReferrerID); err != nil {
-		log.Printf("SetReferrer error: %v", err)
-		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to set referrer")
+	if err := h.service.AddFriend(c.Request.Context(), c.Param("id"), req.FriendID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidFriend):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		default:
+			log.Printf("AddFriend error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to add friend")
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
 
-func (h *UserHandler) GetLeaderboard(c *gin.Context) {
-	entries, err := h.service.GetLeaderboard(c.Request.Context(), 10)
-	if err != nil {
-		log.Printf("GetLeaderboard error: %v", err)
-		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get leaderboard")
+func (h *UserHandler) RemoveFriend(c *gin.Context) {
+	if err := h.service.RemoveFriend(c.Request.Context(), c.Param("id"), c.Param("friend_id")); err != nil {
+		log.Printf("RemoveFriend error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to remove friend")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// SetEmailVerified — админский эндпоинт: отмечает email пользователя подтверждённым или снимает отметку.
+func (h *UserHandler) SetEmailVerified(c *gin.Context) {
+	var req struct {
+		Verified *bool `json:"verified" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	if err := h.service.SetEmailVerified(c.Request.Context(), c.Param("id"), *req.Verified); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		} else {
+			log.Printf("SetEmailVerified error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to set email verification")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// SetCohort — админский эндпоинт: включает пользователя в когорту или, при пустом value, исключает.
+func (h *UserHandler) SetCohort(c *gin.Context) {
+	var req struct {
+		Kind  string `json:"kind" binding:"required"`
+		Value string `json:"value"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
 		return
 	}
 
-	c.JSON(http.StatusOK, entries)
+	if err := h.service.SetCohort(c.Request.Context(), c.Param("id"), req.Kind, req.Value); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCohortKind), errors.Is(err, service.ErrInvalidCohortValue):
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			h.sendError(c, http.StatusNotFound, CodeUserNotFound, "user not found")
+		default:
+			log.Printf("SetCohort error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to set cohort")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
 }
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"Test/internal/model"
+	"Test/internal/repository"
+	"Test/internal/service"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CodeWheelNotConfigured = "wheel_not_configured"
+
+type WheelHandler struct {
+	service *service.WheelService
+}
+
+func NewWheelHandler(service *service.WheelService) *WheelHandler {
+	return &WheelHandler{service: service}
+}
+
+func (h *WheelHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+func (h *WheelHandler) GetTable(c *gin.Context) {
+	table, err := h.service.GetTable(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, repository.ErrWheelNotConfigured) {
+			h.sendError(c, http.StatusNotFound, CodeWheelNotConfigured, err.Error())
+		} else {
+			log.Printf("GetWheelTable error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get prize table")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+func (h *WheelHandler) Spin(c *gin.Context) {
+	spin, err := h.service.Spin(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrWheelNotConfigured):
+			h.sendError(c, http.StatusNotFound, CodeWheelNotConfigured, err.Error())
+		case errors.Is(err, repository.ErrInsufficientPoints):
+			h.sendError(c, http.StatusConflict, CodeInsufficientPoints, err.Error())
+		default:
+			log.Printf("SpinWheel error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to spin the wheel")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, spin)
+}
+
+func (h *WheelHandler) GetSeed(c *gin.Context) {
+	seed, err := h.service.GetSeed(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		log.Printf("GetWheelSeed error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to get wheel seed")
+		return
+	}
+
+	c.JSON(http.StatusOK, seed)
+}
+
+// RotateSeed раскрывает текущий серверный сид; тело необязательно: {"client_seed": "..."}.
+func (h *WheelHandler) RotateSeed(c *gin.Context) {
+	var req struct {
+		ClientSeed string `json:"client_seed"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	revealed, next, err := h.service.RotateSeed(c.Request.Context(), c.Param("id"), req.ClientSeed)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidClientSeed) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("RotateWheelSeed error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to rotate wheel seed")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"revealed": revealed,
+		"current":  next,
+	})
+}
+
+func (h *WheelHandler) ListUserSpins(c *gin.Context) {
+	h.listSpins(c, c.Param("id"))
+}
+
+// ListAllSpins — админский журнал вращений; ?user_id= сужает до одного пользователя.
+func (h *WheelHandler) ListAllSpins(c *gin.Context) {
+	h.listSpins(c, c.Query("user_id"))
+}
+
+func (h *WheelHandler) listSpins(c *gin.Context, userID string) {
+	limit, offset, err := parsePagination(c, defaultPageLimit)
+	if err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		return
+	}
+
+	page, err := h.service.ListSpins(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		log.Printf("ListWheelSpins error: %v", err)
+		h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to list wheel spins")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SetPrizes — админский эндпоинт: заменяет таблицу призов новой версией.
+func (h *WheelHandler) SetPrizes(c *gin.Context) {
+	var req struct {
+		Prizes []struct {
+			Label  string `json:"label"`
+			Points int    `json:"points"`
+			Weight int    `json:"weight"`
+		} `json:"prizes" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, "invalid request format")
+		return
+	}
+
+	prizes := make([]model.WheelPrize, 0, len(req.Prizes))
+	for _, p := range req.Prizes {
+		prizes = append(prizes, model.WheelPrize{Label: p.Label, Points: p.Points, Weight: p.Weight})
+	}
+
+	table, err := h.service.SetPrizes(c.Request.Context(), prizes)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidWheel) {
+			h.sendError(c, http.StatusBadRequest, CodeInvalidRequest, err.Error())
+		} else {
+			log.Printf("SetWheelPrizes error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to update prize table")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, table)
+}
+
+func (h *WheelHandler) Report(c *gin.Context) {
+	report, err := h.service.Report(c.Request.Context())
+	if err != nil {
+		if errors.Is(err, repository.ErrWheelNotConfigured) {
+			h.sendError(c, http.StatusNotFound, CodeWheelNotConfigured, err.Error())
+		} else {
+			log.Printf("WheelReport error: %v", err)
+			h.sendError(c, http.StatusInternalServerError, CodeInternalError, "failed to build wheel report")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"Test/internal/gateway"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	CodeInvalidTopic = "invalid_topic"
+
+	// wsWriteTimeout — сколько ждать записи в сокет, прежде чем считать клиента зависшим
+	wsWriteTimeout = 10 * time.Second
+	wsMaxMessage   = 4096
+)
+
+type WSHandler struct {
+	hub       *gateway.Hub
+	heartbeat time.Duration
+}
+
+func NewWSHandler(hub *gateway.Hub, heartbeat time.Duration) *WSHandler {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &WSHandler{hub: hub, heartbeat: heartbeat}
+}
+
+func (h *WSHandler) sendError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, gin.H{
+		"error": message,
+		"code":  code,
+	})
+}
+
+// wsRequest — команда клиента: {"action": "subscribe"|"unsubscribe", "topics": [...]}.
+type wsRequest struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// Connect поднимает WebSocket-соединение для авторизованного пользователя.
+func (h *WSHandler) Connect(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		h.sendError(c, http.StatusUnauthorized, CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	// Токен уже проверен middleware, поэтому проверка Origin не нужна
+	server := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			conn.MaxPayloadBytes = wsMaxMessage
+			h.serve(conn, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *WSHandler) serve(conn *websocket.Conn, userID string) {
+	defer conn.Close()
+
+	client := h.hub.NewClient(userID)
+	defer h.hub.Unregister(client)
+
+	go h.readLoop(conn, client)
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-client.Done():
+			if client.Lagged() {
+				h.write(conn, []byte(`{"type":"error","code":"too_slow","error":"client is too slow"}`))
+			}
+			return
+		case payload := <-client.Messages():
+			if err := h.write(conn, payload); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := h.write(conn, []byte(`{"type":"ping"}`)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (h *WSHandler) write(conn *websocket.Conn, payload []byte) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout)); err != nil {
+		return err
+	}
+	return websocket.Message.Send(conn, string(payload))
+}
+
+// readLoop обрабатывает команды клиента; при разрыве соединения отключает клиента от хаба.
+func (h *WSHandler) readLoop(conn *websocket.Conn, client *gateway.Client) {
+	defer h.hub.Unregister(client)
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(conn, &data); err != nil {
+			return
+		}
+
+		var req wsRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			client.Send(gateway.Message{Type: "error", Code: CodeInvalidRequest, Error: "Invalid message"})
+			continue
+		}
+
+		switch req.Action {
+		case "subscribe":
+			if err := h.hub.Subscribe(client, req.Topics); err != nil {
+				client.Send(gateway.Message{Type: "error", Code: CodeInvalidTopic, Error: err.Error(), Topics: req.Topics})
+				continue
+			}
+			client.Send(gateway.Message{Type: "subscribed", Topics: req.Topics})
+		case "unsubscribe":
+			h.hub.Unsubscribe(client, req.Topics)
+			client.Send(gateway.Message{Type: "unsubscribed", Topics: req.Topics})
+		default:
+			client.Send(gateway.Message{Type: "error", Code: CodeInvalidRequest, Error: "action must be subscribe or unsubscribe"})
+		}
+	}
+}
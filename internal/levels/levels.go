@@ -0,0 +1,106 @@
+package levels
+
+import (
+	"Test/config"
+	"Test/internal/model"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Curve — кривая уровней по опыту и тиры, которые открываются на уровнях.
+// Нулевая Curve (без порогов) означает, что уровни выключены.
+type Curve struct {
+	thresholds []int
+	tiers      []config.TierConfig
+}
+
+// New проверяет конфигурацию: пороги начинаются с 0 и строго возрастают,
+// тиры упорядочены по минимальному уровню и первый открывается с уровня 1.
+func New(cfg config.LevelsConfig) (*Curve, error) {
+	if len(cfg.Thresholds) == 0 {
+		return &Curve{}, nil
+	}
+	if cfg.Thresholds[0] != 0 {
+		return nil, errors.New("levels: first threshold must be 0")
+	}
+	for i := 1; i < len(cfg.Thresholds); i++ {
+		if cfg.Thresholds[i] <= cfg.Thresholds[i-1] {
+			return nil, fmt.Errorf("levels: threshold %d must be greater than the previous one", i+1)
+		}
+	}
+
+	tiers := make([]config.TierConfig, len(cfg.Tiers))
+	copy(tiers, cfg.Tiers)
+	for i, tier := range tiers {
+		tiers[i].Name = strings.ToLower(strings.TrimSpace(tier.Name))
+		switch {
+		case tiers[i].Name == "":
+			return nil, fmt.Errorf("levels: tier %d has no name", i+1)
+		case tier.Multiplier <= 0:
+			return nil, fmt.Errorf("levels: tier %s must have a positive multiplier", tiers[i].Name)
+		case i == 0 && tier.MinLevel != 1:
+			return nil, errors.New("levels: first tier must start at level 1")
+		case i > 0 && tier.MinLevel <= tiers[i-1].MinLevel:
+			return nil, fmt.Errorf("levels: tier %s must start above the previous tier", tiers[i].Name)
+		}
+	}
+
+	return &Curve{thresholds: cfg.Thresholds, tiers: tiers}, nil
+}
+
+// Enabled сообщает, настроены ли уровни.
+func (c *Curve) Enabled() bool {
+	return len(c.thresholds) > 0
+}
+
+// Level возвращает уровень для xp; уровни нумеруются с 1.
+func (c *Curve) Level(xp int) int {
+	return sort.Search(len(c.thresholds), func(i int) bool { return c.thresholds[i] > xp })
+}
+
+// Tier возвращает тир уровня; ok = false, если тиры не настроены.
+func (c *Curve) Tier(level int) (tier config.TierConfig, ok bool) {
+	for _, t := range c.tiers {
+		if level < t.MinLevel {
+			break
+		}
+		tier, ok = t, true
+	}
+	return tier, ok
+}
+
+// Multiplier возвращает множитель очков за задания для пользователя с опытом xp.
+func (c *Curve) Multiplier(xp int) float64 {
+	if tier, ok := c.Tier(c.Level(xp)); ok {
+		return tier.Multiplier
+	}
+	return 1
+}
+
+// Status описывает уровень пользователя с опытом xp и прогресс до следующего.
+func (c *Curve) Status(xp int) *model.LevelStatus {
+	if !c.Enabled() {
+		return nil
+	}
+
+	level := c.Level(xp)
+	status := &model.LevelStatus{
+		Level:      level,
+		XP:         xp,
+		LevelXP:    c.thresholds[level-1],
+		Progress:   1,
+		Multiplier: 1,
+	}
+	if level < len(c.thresholds) {
+		next := c.thresholds[level]
+		status.NextLevelXP = &next
+		status.Progress = float64(xp-status.LevelXP) / float64(next-status.LevelXP)
+	}
+	if tier, ok := c.Tier(level); ok {
+		status.Tier = tier.Name
+		status.Multiplier = tier.Multiplier
+	}
+	return status
+}
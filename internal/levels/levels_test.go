@@ -0,0 +1,156 @@
+package levels
+
+import (
+	"Test/config"
+	"Test/internal/model"
+	"reflect"
+	"testing"
+)
+
+func testCurve(t *testing.T) *Curve {
+	t.Helper()
+	c, err := New(config.LevelsConfig{
+		Thresholds: []int{0, 100, 300, 600},
+		Tiers: []config.TierConfig{
+			{Name: " Bronze ", MinLevel: 1, Multiplier: 1},
+			{Name: "Silver", MinLevel: 2, Multiplier: 1.25},
+			{Name: "Gold", MinLevel: 4, Multiplier: 1.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestNewValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.LevelsConfig
+		wantErr bool
+	}{
+		{name: "disabled", cfg: config.LevelsConfig{}},
+		{name: "thresholds only", cfg: config.LevelsConfig{Thresholds: []int{0, 10}}},
+		{name: "first threshold not zero", cfg: config.LevelsConfig{Thresholds: []int{10, 20}}, wantErr: true},
+		{name: "equal thresholds", cfg: config.LevelsConfig{Thresholds: []int{0, 10, 10}}, wantErr: true},
+		{name: "decreasing thresholds", cfg: config.LevelsConfig{Thresholds: []int{0, 20, 10}}, wantErr: true},
+		{
+			name:    "tier without name",
+			cfg:     config.LevelsConfig{Thresholds: []int{0}, Tiers: []config.TierConfig{{Name: " ", MinLevel: 1, Multiplier: 1}}},
+			wantErr: true,
+		},
+		{
+			name:    "zero multiplier",
+			cfg:     config.LevelsConfig{Thresholds: []int{0}, Tiers: []config.TierConfig{{Name: "a", MinLevel: 1}}},
+			wantErr: true,
+		},
+		{
+			name:    "first tier above level 1",
+			cfg:     config.LevelsConfig{Thresholds: []int{0}, Tiers: []config.TierConfig{{Name: "a", MinLevel: 2, Multiplier: 1}}},
+			wantErr: true,
+		},
+		{
+			name: "tiers out of order",
+			cfg: config.LevelsConfig{Thresholds: []int{0}, Tiers: []config.TierConfig{
+				{Name: "a", MinLevel: 1, Multiplier: 1},
+				{Name: "b", MinLevel: 1, Multiplier: 2},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLevel(t *testing.T) {
+	c := testCurve(t)
+
+	tests := []struct {
+		xp   int
+		want int
+	}{
+		{xp: 0, want: 1},
+		{xp: 99, want: 1},
+		{xp: 100, want: 2},
+		{xp: 299, want: 2},
+		{xp: 300, want: 3},
+		{xp: 600, want: 4},
+		{xp: 10000, want: 4},
+	}
+
+	for _, tt := range tests {
+		if got := c.Level(tt.xp); got != tt.want {
+			t.Fatalf("Level(%d) = %d, want %d", tt.xp, got, tt.want)
+		}
+	}
+}
+
+func TestMultiplier(t *testing.T) {
+	c := testCurve(t)
+
+	tests := []struct {
+		xp   int
+		want float64
+	}{
+		{xp: 0, want: 1},
+		{xp: 100, want: 1.25},
+		{xp: 300, want: 1.25},
+		{xp: 600, want: 1.5},
+	}
+
+	for _, tt := range tests {
+		if got := c.Multiplier(tt.xp); got != tt.want {
+			t.Fatalf("Multiplier(%d) = %v, want %v", tt.xp, got, tt.want)
+		}
+	}
+
+	if got := (&Curve{}).Multiplier(500); got != 1 {
+		t.Fatalf("Multiplier without tiers = %v, want 1", got)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	c := testCurve(t)
+	next := func(xp int) *int { return &xp }
+
+	tests := []struct {
+		name string
+		xp   int
+		want *model.LevelStatus
+	}{
+		{
+			name: "start of first level",
+			xp:   0,
+			want: &model.LevelStatus{Level: 1, XP: 0, LevelXP: 0, NextLevelXP: next(100), Progress: 0, Tier: "bronze", Multiplier: 1},
+		},
+		{
+			name: "middle of a level",
+			xp:   200,
+			want: &model.LevelStatus{Level: 2, XP: 200, LevelXP: 100, NextLevelXP: next(300), Progress: 0.5, Tier: "silver", Multiplier: 1.25},
+		},
+		{
+			name: "last level",
+			xp:   900,
+			want: &model.LevelStatus{Level: 4, XP: 900, LevelXP: 600, Progress: 1, Tier: "gold", Multiplier: 1.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Status(tt.xp); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Status(%d) = %+v, want %+v", tt.xp, got, tt.want)
+			}
+		})
+	}
+
+	if got := (&Curve{}).Status(100); got != nil {
+		t.Fatalf("Status with levels disabled = %+v, want nil", got)
+	}
+}
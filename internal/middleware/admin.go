@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"Test/internal/repository"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const CodeForbidden = "forbidden"
+
+// RequireAdmin пропускает только пользователей с флагом is_admin.
+// Должен стоять после AuthMiddleware.
+func RequireAdmin(users repository.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isAdmin, err := users.IsAdmin(c.Request.Context(), c.GetString("user_id"))
+		if err != nil {
+			log.Printf("RequireAdmin error: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+				"error": "internal server error",
+				"code":  "internal_error",
+			})
+			return
+		}
+		if !isAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "admin access required",
+				"code":  CodeForbidden,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
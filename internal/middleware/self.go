@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireSelf пропускает запрос, только если :id в пути совпадает с пользователем из токена.
+// Должен стоять после AuthMiddleware.
+func RequireSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("id") != c.GetString("user_id") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "action is allowed only on your own account",
+				"code":  CodeForbidden,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -8,22 +8,110 @@ type User struct {
 	Email     string    `json:"email"`
 	Password  string    `json:"-"`
 	Points    int       `json:"points"`
+	XP        int       `json:"xp"`
 	Referrer  *string   `json:"referrer,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	NormalizedEmail string           `json:"-"`
+	Registration    RegistrationInfo `json:"-"`
+}
+
+// RegistrationInfo — данные клиента на момент регистрации, используются для поиска накруток.
+type RegistrationInfo struct {
+	IP        string `json:"ip"`
+	UserAgent string `json:"user_agent"`
+	DeviceID  string `json:"device_id"`
+	Country   string `json:"country"`
 }
 
+// Currency — валюта баланса: очки тратятся в магазине, опыт только накапливается.
+type Currency string
+
+const (
+	CurrencyPoints Currency = "points"
+	CurrencyXP     Currency = "xp"
+)
+
 type Task struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Points      int    `json:"points"`
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Repeatable  bool             `json:"repeatable"`
+	Rewards     map[Currency]int `json:"rewards"`
 }
 
 type UserStatus struct {
-	User           User   `json:"user"`
-	CompletedTasks []Task `json:"completed_tasks"`
-	Referrals      []User `json:"referrals,omitempty"`
+	User           User             `json:"user"`
+	CompletedTasks []Task           `json:"completed_tasks"`
+	Referrals      []User           `json:"referrals,omitempty"`
+	Currency       Currency         `json:"currency"`
+	Balance        int              `json:"balance"`
+	Level          *LevelStatus     `json:"level,omitempty"`
+	Badges         []UserBadge      `json:"badges"`
+	Streak         *StreakStatus    `json:"streak,omitempty"`
+	ExpiringSoon   []ExpiringPoints `json:"expiring_soon,omitempty"`
+}
+
+// PublicProfile — то, что о пользователе видят другие пользователи.
+type PublicProfile struct {
+	UserID string       `json:"user_id"`
+	Name   string       `json:"name"`
+	Level  *LevelStatus `json:"level,omitempty"`
+	Badges []UserBadge  `json:"badges"`
+}
+
+// BadgeRule — показатель, по которому выдаётся значок: значок получают,
+// когда показатель достигает Threshold (для season_rank — место не ниже Threshold).
+type BadgeRule string
+
+const (
+	BadgeRuleTasksCompleted BadgeRule = "tasks_completed"
+	BadgeRuleReferrals      BadgeRule = "referrals"
+	BadgeRuleTaskStreak     BadgeRule = "task_streak"
+	BadgeRuleSeasonRank     BadgeRule = "season_rank"
+	BadgeRuleCheckinStreak  BadgeRule = "checkin_streak"
+)
+
+type Badge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Rule        BadgeRule `json:"rule"`
+	Threshold   int       `json:"threshold"`
+}
+
+type UserBadge struct {
+	UserID      string    `json:"-"`
+	BadgeID     string    `json:"badge_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
+}
+
+// LevelStatus — уровень пользователя по опыту и прогресс до следующего уровня.
+type LevelStatus struct {
+	Level int `json:"level"`
+	XP    int `json:"xp"`
+	// Опыт, с которого начинается текущий и следующий уровень; NextLevelXP = nil на последнем уровне
+	LevelXP     int     `json:"level_xp"`
+	NextLevelXP *int    `json:"next_level_xp,omitempty"`
+	Progress    float64 `json:"progress"`
+	Tier        string  `json:"tier,omitempty"`
+	Multiplier  float64 `json:"multiplier"`
+}
+
+// LevelUp — переход пользователя на новый уровень.
+type LevelUp struct {
+	Level         int    `json:"level"`
+	PreviousLevel int    `json:"previous_level"`
+	Tier          string `json:"tier,omitempty"`
+}
+
+// ExpiringPoints — сколько очков сгорит в указанный день.
+type ExpiringPoints struct {
+	Amount    int    `json:"amount"`
+	ExpiresOn string `json:"expires_on"`
 }
 
 type LeaderboardEntry struct {
@@ -33,8 +121,667 @@ type LeaderboardEntry struct {
 	Position int    `json:"position"`
 }
 
+type ReferralStatus string
+
+const (
+	ReferralPending ReferralStatus = "pending"
+	ReferralPaid    ReferralStatus = "paid"
+	ReferralVoid    ReferralStatus = "void"
+	ReferralReview  ReferralStatus = "review"
+)
+
+type LeaderboardPeriod string
+
+const (
+	PeriodDay   LeaderboardPeriod = "day"
+	PeriodWeek  LeaderboardPeriod = "week"
+	PeriodMonth LeaderboardPeriod = "month"
+	PeriodAll   LeaderboardPeriod = "all"
+)
+
+// LeaderboardScope ограничивает круг пользователей в рейтинге.
+type LeaderboardScope string
+
+const (
+	ScopeGlobal  LeaderboardScope = "global"
+	ScopeFriends LeaderboardScope = "friends"
+	ScopeNetwork LeaderboardScope = "network"
+	ScopeCohort  LeaderboardScope = "cohort"
+)
+
+// Встроенные когорты; остальные задаются в user_cohorts.
+const (
+	CohortSignupMonth = "signup_month"
+	CohortCountry     = "country"
+)
+
+// LeaderboardVisibility — видимость пользователя в рейтингах.
+type LeaderboardVisibility string
+
+const (
+	VisibilityVisible LeaderboardVisibility = "visible"
+	// VisibilityExcluded — пользователь не попадает ни в один рейтинг
+	VisibilityExcluded LeaderboardVisibility = "excluded"
+	// VisibilityShadow — пользователь видит себя в рейтинге, остальные его не видят
+	VisibilityShadow LeaderboardVisibility = "shadow"
+)
+
+// LeaderboardQuery описывает выборку рейтинга; без Since и Until — рейтинг за всё время.
+// Для Scope, отличного от global, круг пользователей строится относительно ScopeUserID.
+// ViewerID — кто смотрит рейтинг: скрытый пользователь видит в нём себя.
+type LeaderboardQuery struct {
+	Currency    Currency
+	Since       *time.Time
+	Until       *time.Time
+	Scope       LeaderboardScope
+	ScopeUserID string
+	Cohort      string
+	ViewerID    string
+	Limit       int
+	Offset      int
+}
+
+// UserScore — текущий опыт пользователя и момент, когда он был достигнут.
+type UserScore struct {
+	UserID    string
+	Name      string
+	XP        int
+	ReachedAt time.Time
+}
+
+type HiddenUser struct {
+	UserID     string                `json:"user_id"`
+	Name       string                `json:"name"`
+	Points     int                   `json:"points"`
+	Visibility LeaderboardVisibility `json:"visibility"`
+}
+
+type HiddenUsersPage struct {
+	Users  []HiddenUser `json:"users"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// RankingCheck — результат сверки in-memory рейтинга с базой.
+type RankingCheck struct {
+	CheckedAt  time.Time `json:"checked_at"`
+	Users      int       `json:"users"`
+	Indexed    int       `json:"indexed"`
+	Missing    int       `json:"missing"`
+	Stale      int       `json:"stale"`
+	Extra      int       `json:"extra"`
+	Consistent bool      `json:"consistent"`
+}
+
+type LeaderboardPage struct {
+	Currency Currency           `json:"currency"`
+	Period   LeaderboardPeriod  `json:"period"`
+	Scope    LeaderboardScope   `json:"scope"`
+	Cohort   string             `json:"cohort,omitempty"`
+	From     *time.Time         `json:"from,omitempty"`
+	Entries  []LeaderboardEntry `json:"entries"`
+	Total    int                `json:"total"`
+	Limit    int                `json:"limit"`
+	Offset   int                `json:"offset"`
+	Me       *LeaderboardMe     `json:"me,omitempty"`
+}
+
+type Friend struct {
+	UserID string    `json:"user_id"`
+	Name   string    `json:"name"`
+	Points int       `json:"points"`
+	Since  time.Time `json:"since"`
+}
+
+// LeaderboardMe — позиция вызывающего пользователя и соседи по рейтингу.
+type LeaderboardMe struct {
+	Position  int                `json:"position"`
+	Points    int                `json:"points"`
+	Neighbors []LeaderboardEntry `json:"neighbors"`
+}
+
+type Season struct {
+	ID            int64          `json:"id"`
+	Name          string         `json:"name"`
+	StartsAt      time.Time      `json:"starts_at"`
+	EndsAt        time.Time      `json:"ends_at"`
+	SnapshottedAt *time.Time     `json:"snapshotted_at,omitempty"`
+	Rewards       []SeasonReward `json:"rewards"`
+}
+
+// SeasonReward — награда за места с PositionFrom по PositionTo включительно.
+type SeasonReward struct {
+	PositionFrom int `json:"position_from"`
+	PositionTo   int `json:"position_to"`
+	Points       int `json:"points"`
+}
+
+type SeasonEntry struct {
+	LeaderboardEntry
+	RewardPoints int `json:"reward_points"`
+}
+
+// SeasonStandings — итоговая таблица сезона или, пока снимок не сделан, текущее положение.
+type SeasonStandings struct {
+	Season  Season        `json:"season"`
+	Final   bool          `json:"final"`
+	Entries []SeasonEntry `json:"entries"`
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+}
+
 type Referral struct {
-	ReferrerID string    `json:"referrer_id" db:"referrer_id"`
-	RefereeID  string    `json:"referee_id" db:"referee_id"`
-	Date       time.Time `json:"date" db:"date"`
+	ReferrerID   string         `json:"referrer_id" db:"referrer_id"`
+	RefereeID    string         `json:"referee_id" db:"referee_id"`
+	Date         time.Time      `json:"date" db:"date"`
+	RewardPoints int            `json:"reward_points" db:"reward_points"`
+	Status       ReferralStatus `json:"status" db:"status"`
+	ResolvedAt   *time.Time     `json:"resolved_at,omitempty" db:"resolved_at"`
+	Resolution   string         `json:"resolution,omitempty" db:"resolution"`
+	Flags        []string       `json:"flags,omitempty" db:"flags"`
+}
+
+type FlaggedReferralsPage struct {
+	Items  []Referral `json:"items"`
+	Total  int        `json:"total"`
+	Limit  int        `json:"limit"`
+	Offset int        `json:"offset"`
+}
+
+// InvitedUser — приглашённый пользователь и то, сколько он принёс рефереру.
+type InvitedUser struct {
+	UserID           string         `json:"user_id"`
+	Name             string         `json:"name"`
+	Date             time.Time      `json:"date"`
+	RewardPoints     int            `json:"reward_points"`
+	RewardStatus     ReferralStatus `json:"reward_status"`
+	CommissionPoints int            `json:"commission_points"`
+	EarnedPoints     int            `json:"earned_points"`
+}
+
+type InvitedUsersPage struct {
+	Items  []InvitedUser `json:"items"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// PendingReferral — ожидающая награда вместе с данными реферала, нужными для проверки условий.
+type PendingReferral struct {
+	Referral
+	RefereeVerifiedAt *time.Time
+	CompletedTasks    int
+	// Дни, в которые реферал выполнял задания или отмечался
+	ActiveDays int
+}
+
+type ReferralNode struct {
+	UserID     string          `json:"user_id"`
+	ReferrerID string          `json:"referrer_id"`
+	Name       string          `json:"name"`
+	Level      int             `json:"level"`
+	Points     int             `json:"points"`
+	JoinedAt   time.Time       `json:"joined_at"`
+	Referrals  []*ReferralNode `json:"referrals,omitempty"`
+}
+
+type ReferralLevelStats struct {
+	Level            int `json:"level"`
+	Count            int `json:"count"`
+	CommissionPoints int `json:"commission_points"`
+}
+
+type ReferralTree struct {
+	UserID          string               `json:"user_id"`
+	MaxDepth        int                  `json:"max_depth"`
+	Levels          []ReferralLevelStats `json:"levels"`
+	TotalCommission int                  `json:"total_commission"`
+	Downline        []*ReferralNode      `json:"downline"`
+}
+
+type LedgerReason string
+
+const (
+	LedgerOpeningBalance     LedgerReason = "opening_balance"
+	LedgerTask               LedgerReason = "task"
+	LedgerReferralReward     LedgerReason = "referral_reward"
+	LedgerReferralCommission LedgerReason = "referral_commission"
+	LedgerAdjustment         LedgerReason = "adjustment"
+	LedgerSeasonReward       LedgerReason = "season_reward"
+	LedgerRewardRedemption   LedgerReason = "reward_redemption"
+	LedgerRewardRefund       LedgerReason = "reward_refund"
+	LedgerPromoCode          LedgerReason = "promo_code"
+	LedgerTransferOut        LedgerReason = "transfer_out"
+	LedgerTransferIn         LedgerReason = "transfer_in"
+	LedgerPointsExpired      LedgerReason = "points_expired"
+	LedgerCheckin            LedgerReason = "checkin"
+	LedgerStreakFreeze       LedgerReason = "streak_freeze"
+	LedgerWheelSpin          LedgerReason = "wheel_spin"
+	LedgerWheelPrize         LedgerReason = "wheel_prize"
+	LedgerRaffleTicket       LedgerReason = "raffle_ticket"
+	LedgerRaffleRefund       LedgerReason = "raffle_refund"
+	LedgerRafflePrize        LedgerReason = "raffle_prize"
+	LedgerTeamChallenge      LedgerReason = "team_challenge"
+	LedgerCommunityGoal      LedgerReason = "community_goal"
+)
+
+// BalanceChange — изменение баланса пользователя в результате операции.
+type BalanceChange struct {
+	UserID   string       `json:"user_id"`
+	Currency Currency     `json:"currency"`
+	Delta    int          `json:"delta"`
+	Balance  int          `json:"balance"`
+	Reason   LedgerReason `json:"reason"`
+}
+
+type TaskCompletion struct {
+	UserID  string          `json:"user_id"`
+	Task    Task            `json:"task"`
+	Changes []BalanceChange `json:"changes"`
+}
+
+type LedgerEntry struct {
+	ID          int64        `json:"id"`
+	UserID      string       `json:"user_id"`
+	Amount      int          `json:"amount"`
+	Reason      LedgerReason `json:"reason"`
+	ReferenceID string       `json:"reference_id,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type ReferralStats struct {
+	ReferrerID        string  `json:"referrer_id,omitempty"`
+	Clicks            int     `json:"clicks"`
+	Signups           int     `json:"signups"`
+	Qualified         int     `json:"qualified"`
+	SignupRate        float64 `json:"signup_rate"`
+	QualificationRate float64 `json:"qualification_rate"`
+	PointsPaid        int     `json:"points_paid"`
+}
+
+type ReferralStatsReport struct {
+	From      time.Time       `json:"from"`
+	To        time.Time       `json:"to"`
+	Totals    ReferralStats   `json:"totals"`
+	Referrers []ReferralStats `json:"referrers,omitempty"`
+}
+
+// Reward — позиция каталога наград. Stock и PerUserLimit равны nil, если ограничений нет.
+type Reward struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Cost         int       `json:"cost"`
+	Stock        *int      `json:"stock"`
+	PerUserLimit *int      `json:"per_user_limit"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderFulfilled OrderStatus = "fulfilled"
+	OrderCancelled OrderStatus = "cancelled"
+)
+
+type RewardOrder struct {
+	ID          int64       `json:"id"`
+	RewardID    int64       `json:"reward_id"`
+	RewardName  string      `json:"reward_name"`
+	UserID      string      `json:"user_id"`
+	Cost        int         `json:"cost"`
+	Status      OrderStatus `json:"status"`
+	Note        string      `json:"note,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	FulfilledAt *time.Time  `json:"fulfilled_at,omitempty"`
+	CancelledAt *time.Time  `json:"cancelled_at,omitempty"`
+}
+
+type RewardOrdersPage struct {
+	Orders []RewardOrder `json:"orders"`
+	Total  int           `json:"total"`
+	Limit  int           `json:"limit"`
+	Offset int           `json:"offset"`
+}
+
+// Redemption — результат обмена очков на награду.
+type Redemption struct {
+	Order   RewardOrder `json:"order"`
+	Balance int         `json:"balance"`
+}
+
+// PromoBatch — партия промокодов с общими условиями. MaxUses ограничивает активации
+// каждого кода, MaxUsesPerUser — число кодов партии на одного пользователя.
+type PromoBatch struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	Points         int        `json:"points"`
+	MaxUses        *int       `json:"max_uses"`
+	MaxUsesPerUser int        `json:"max_uses_per_user"`
+	ValidFrom      *time.Time `json:"valid_from,omitempty"`
+	ValidUntil     *time.Time `json:"valid_until,omitempty"`
+	Codes          int        `json:"codes"`
+	Redemptions    int        `json:"redemptions"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+type PromoCode struct {
+	Code string `json:"code"`
+	Uses int    `json:"uses"`
+}
+
+type PromoRedemption struct {
+	Code    string `json:"code"`
+	Points  int    `json:"points"`
+	Balance int    `json:"balance"`
+}
+
+type Transfer struct {
+	ID          int64     `json:"id"`
+	SenderID    string    `json:"sender_id"`
+	RecipientID string    `json:"recipient_id"`
+	Amount      int       `json:"amount"`
+	Memo        string    `json:"memo"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TransferResult — итог перевода. Replayed — запрос с тем же ключом идемпотентности
+// уже был выполнен, и возвращён сохранённый перевод без повторного списания.
+type TransferResult struct {
+	Transfer Transfer        `json:"transfer"`
+	Balance  int             `json:"balance"`
+	Replayed bool            `json:"replayed"`
+	Changes  []BalanceChange `json:"-"`
+}
+
+type TransfersPage struct {
+	Transfers []Transfer `json:"transfers"`
+	Total     int        `json:"total"`
+	Limit     int        `json:"limit"`
+	Offset    int        `json:"offset"`
+}
+
+// Streak — сохранённое состояние серии ежедневных отметок.
+type Streak struct {
+	Current     int
+	Longest     int
+	LastCheckin *time.Time
+	Freezes     int
+	Timezone    string
+}
+
+// StreakStatus — серия отметок с точки зрения пользователя: Current = 0,
+// если серия уже прервана пропуском, который не покрыть заморозками.
+type StreakStatus struct {
+	Current        int    `json:"current"`
+	Longest        int    `json:"longest"`
+	LastCheckin    string `json:"last_checkin,omitempty"`
+	CheckedInToday bool   `json:"checked_in_today"`
+	Freezes        int    `json:"freezes"`
+	NextReward     int    `json:"next_reward"`
+	Timezone       string `json:"timezone"`
+}
+
+type CheckIn struct {
+	Day         string        `json:"day"`
+	Timezone    string        `json:"timezone"`
+	Streak      int           `json:"streak"`
+	Reward      int           `json:"reward"`
+	FreezesUsed int           `json:"freezes_used"`
+	Balance     int           `json:"balance"`
+	Change      BalanceChange `json:"-"`
+}
+
+type FreezePurchase struct {
+	Freezes int           `json:"freezes"`
+	Balance int           `json:"balance"`
+	Change  BalanceChange `json:"-"`
+}
+
+type WheelPrize struct {
+	Position    int     `json:"position"`
+	Label       string  `json:"label"`
+	Points      int     `json:"points"`
+	Weight      int     `json:"weight"`
+	Probability float64 `json:"probability"`
+}
+
+// WheelTable — версия таблицы призов колеса.
+type WheelTable struct {
+	ID            int64        `json:"id"`
+	Prizes        []WheelPrize `json:"prizes"`
+	ExpectedValue float64      `json:"expected_value"`
+	SpinCost      int          `json:"spin_cost"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// WheelSeed — пара сидов пользователя. ServerSeed раскрывается только после смены пары.
+type WheelSeed struct {
+	ID             int64      `json:"id"`
+	ServerSeedHash string     `json:"server_seed_hash"`
+	ServerSeed     string     `json:"server_seed,omitempty"`
+	ClientSeed     string     `json:"client_seed"`
+	Nonce          int        `json:"nonce"`
+	CreatedAt      time.Time  `json:"created_at"`
+	RevealedAt     *time.Time `json:"revealed_at,omitempty"`
+}
+
+type WheelSpin struct {
+	ID             int64           `json:"id"`
+	UserID         string          `json:"user_id"`
+	SeedID         int64           `json:"seed_id"`
+	ServerSeedHash string          `json:"server_seed_hash"`
+	ServerSeed     string          `json:"server_seed,omitempty"`
+	ClientSeed     string          `json:"client_seed"`
+	Nonce          int             `json:"nonce"`
+	Digest         string          `json:"digest"`
+	TableID        int64           `json:"table_id"`
+	Prize          WheelPrize      `json:"prize"`
+	Cost           int             `json:"cost"`
+	Free           bool            `json:"free"`
+	Balance        int             `json:"balance"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Changes        []BalanceChange `json:"-"`
+}
+
+type WheelSpinsPage struct {
+	Spins  []WheelSpin `json:"spins"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
+
+// WheelPrizeStats — ожидаемая и фактическая частота выпадения приза.
+type WheelPrizeStats struct {
+	WheelPrize
+	Hits         int     `json:"hits"`
+	ExpectedHits float64 `json:"expected_hits"`
+}
+
+// WheelReport — ожидаемая и фактическая отдача текущей таблицы призов.
+type WheelReport struct {
+	TableID       int64   `json:"table_id"`
+	SpinCost      int     `json:"spin_cost"`
+	ExpectedValue float64 `json:"expected_value"`
+	// Доля стоимости вращения, которая в среднем возвращается призами
+	ExpectedReturn float64           `json:"expected_return"`
+	Spins          int               `json:"spins"`
+	FreeSpins      int               `json:"free_spins"`
+	PointsSpent    int               `json:"points_spent"`
+	PointsPaid     int               `json:"points_paid"`
+	ActualReturn   float64           `json:"actual_return"`
+	Prizes         []WheelPrizeStats `json:"prizes"`
+}
+
+type RaffleStatus string
+
+const (
+	RaffleOpen      RaffleStatus = "open"
+	RaffleDrawn     RaffleStatus = "drawn"
+	RaffleCancelled RaffleStatus = "cancelled"
+)
+
+// Raffle — розыгрыш среди купивших билеты. MaxTicketsPerUser = 0 — без ограничения.
+// Серверный сид раскрывается после розыгрыша или отмены, до этого виден только его хеш.
+type Raffle struct {
+	ID                int64          `json:"id"`
+	Title             string         `json:"title"`
+	Description       string         `json:"description"`
+	Prize             string         `json:"prize"`
+	PrizePoints       int            `json:"prize_points"`
+	TicketPrice       int            `json:"ticket_price"`
+	MaxTicketsPerUser int            `json:"max_tickets_per_user"`
+	Winners           int            `json:"winners"`
+	DrawAt            time.Time      `json:"draw_at"`
+	Status            RaffleStatus   `json:"status"`
+	TicketsSold       int            `json:"tickets_sold"`
+	Participants      int            `json:"participants"`
+	ServerSeedHash    string         `json:"server_seed_hash"`
+	ServerSeed        string         `json:"server_seed,omitempty"`
+	ClientSeed        string         `json:"client_seed,omitempty"`
+	CreatedAt         time.Time      `json:"created_at"`
+	DrawnAt           *time.Time     `json:"drawn_at,omitempty"`
+	CancelledAt       *time.Time     `json:"cancelled_at,omitempty"`
+	Results           []RaffleWinner `json:"results,omitempty"`
+}
+
+type RaffleWinner struct {
+	Place  int    `json:"place"`
+	UserID string `json:"user_id"`
+	Nonce  int    `json:"nonce"`
+	Digest string `json:"digest"`
+}
+
+// RaffleEntry — билеты участника; участники идут в порядке первой покупки,
+// и их билеты нумеруются подряд.
+type RaffleEntry struct {
+	UserID      string `json:"user_id"`
+	Tickets     int    `json:"tickets"`
+	FirstTicket int    `json:"first_ticket"`
+	LastTicket  int    `json:"last_ticket"`
+}
+
+type RaffleTicketPurchase struct {
+	ID       int64 `json:"id"`
+	RaffleID int64 `json:"raffle_id"`
+	Quantity int   `json:"quantity"`
+	Cost     int   `json:"cost"`
+	// Сколько всего билетов этого розыгрыша у пользователя после покупки
+	Tickets   int            `json:"tickets"`
+	Balance   int            `json:"balance"`
+	CreatedAt time.Time      `json:"created_at"`
+	Change    *BalanceChange `json:"-"`
+}
+
+type RafflesPage struct {
+	Raffles []Raffle `json:"raffles"`
+	Total   int      `json:"total"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+}
+
+type TeamRole string
+
+const (
+	TeamCaptain TeamRole = "captain"
+	TeamMember  TeamRole = "member"
+)
+
+// Team — команда пользователей. Points — очки, заработанные участниками за время
+// членства в команде. InviteCode виден только участникам.
+type Team struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	CaptainID   string             `json:"captain_id,omitempty"`
+	InviteCode  string             `json:"invite_code,omitempty"`
+	MemberCount int                `json:"member_count"`
+	Points      int                `json:"points"`
+	CreatedAt   time.Time          `json:"created_at"`
+	Members     []TeamMemberStatus `json:"members,omitempty"`
+}
+
+type TeamMemberStatus struct {
+	UserID string   `json:"user_id"`
+	Name   string   `json:"name"`
+	Role   TeamRole `json:"role"`
+	// Очки, заработанные в текущем членстве
+	Points   int       `json:"points"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type TeamLeaderboardEntry struct {
+	Position    int    `json:"position"`
+	TeamID      int64  `json:"team_id"`
+	Name        string `json:"name"`
+	MemberCount int    `json:"member_count"`
+	Points      int    `json:"points"`
+}
+
+type TeamLeaderboardPage struct {
+	Entries []TeamLeaderboardEntry `json:"entries"`
+	Total   int                    `json:"total"`
+	Limit   int                    `json:"limit"`
+	Offset  int                    `json:"offset"`
+}
+
+// TeamChallenge — общая цель команды: Goal выполненных участниками заданий (Task —
+// конкретного, пустой — любых) между StartsAt и EndsAt. Награда начисляется
+// каждому участнику команды на момент выполнения.
+type TeamChallenge struct {
+	ID           int64      `json:"id"`
+	TeamID       int64      `json:"team_id"`
+	Title        string     `json:"title"`
+	Task         string     `json:"task,omitempty"`
+	Goal         int        `json:"goal"`
+	Progress     int        `json:"progress"`
+	RewardPoints int        `json:"reward_points"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type CommunityGoalStatus string
+
+const (
+	CommunityGoalUpcoming CommunityGoalStatus = "upcoming"
+	CommunityGoalActive   CommunityGoalStatus = "active"
+	CommunityGoalReached  CommunityGoalStatus = "reached"
+	CommunityGoalRewarded CommunityGoalStatus = "rewarded"
+	CommunityGoalFailed   CommunityGoalStatus = "failed"
+)
+
+// CommunityGoal — общая цель всех пользователей: Goal выполнений задания Task между
+// StartsAt и EndsAt. Награду получает каждый, кто выполнил задание в этом окне.
+type CommunityGoal struct {
+	ID           int64               `json:"id"`
+	Title        string              `json:"title"`
+	Task         string              `json:"task"`
+	Goal         int                 `json:"goal"`
+	Progress     int                 `json:"progress"`
+	RewardPoints int                 `json:"reward_points"`
+	Status       CommunityGoalStatus `json:"status"`
+	StartsAt     time.Time           `json:"starts_at"`
+	EndsAt       time.Time           `json:"ends_at"`
+	ReachedAt    *time.Time          `json:"reached_at,omitempty"`
+	RewardedAt   *time.Time          `json:"rewarded_at,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+type CommunityGoalsPage struct {
+	Goals  []CommunityGoal `json:"goals"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// CommunityGoalProgress — данные события о прогрессе общей цели.
+type CommunityGoalProgress struct {
+	GoalID   int64 `json:"goal_id"`
+	Progress int   `json:"progress"`
+	Goal     int   `json:"goal"`
 }
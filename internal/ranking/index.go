@@ -0,0 +1,262 @@
+package ranking
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	maxLevel    = 32
+	probability = 0.25
+)
+
+// Entry — пользователь в рейтинге. Порядок: больше очков выше, при равенстве
+// выше тот, кто достиг счёта раньше, затем по UserID.
+type Entry struct {
+	UserID    string
+	Name      string
+	Score     int
+	ReachedAt time.Time
+}
+
+func less(a, b *Entry) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if !a.ReachedAt.Equal(b.ReachedAt) {
+		return a.ReachedAt.Before(b.ReachedAt)
+	}
+	return a.UserID < b.UserID
+}
+
+// node хранит для каждого уровня ссылку на следующий узел и span —
+// на сколько позиций в рейтинге эта ссылка перепрыгивает.
+type node struct {
+	entry Entry
+	next  []*node
+	span  []int
+}
+
+// Index — skip list с ширинами ссылок: вставка, удаление, позиция пользователя
+// и выборка по позиции работают за O(log n). Безопасен для конкурентного доступа.
+type Index struct {
+	mu     sync.RWMutex
+	head   *node
+	level  int
+	length int
+	byID   map[string]*node
+	rnd    *rand.Rand
+}
+
+func New() *Index {
+	return &Index{
+		head:  &node{next: make([]*node, maxLevel), span: make([]int, maxLevel)},
+		level: 1,
+		byID:  make(map[string]*node),
+		rnd:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Upsert добавляет пользователя или обновляет его счёт.
+func (x *Index) Upsert(e Entry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if old, ok := x.byID[e.UserID]; ok {
+		if old.entry.Score == e.Score && old.entry.ReachedAt.Equal(e.ReachedAt) {
+			old.entry.Name = e.Name
+			return
+		}
+		x.delete(old)
+	}
+	x.insert(e)
+}
+
+func (x *Index) Remove(userID string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if n, ok := x.byID[userID]; ok {
+		x.delete(n)
+	}
+}
+
+// Replace перестраивает индекс целиком.
+func (x *Index) Replace(entries []Entry) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.head = &node{next: make([]*node, maxLevel), span: make([]int, maxLevel)}
+	x.level = 1
+	x.length = 0
+	x.byID = make(map[string]*node, len(entries))
+	for _, e := range entries {
+		if old, ok := x.byID[e.UserID]; ok {
+			x.delete(old)
+		}
+		x.insert(e)
+	}
+}
+
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return x.length
+}
+
+func (x *Index) Get(userID string) (Entry, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	n, ok := x.byID[userID]
+	if !ok {
+		return Entry{}, false
+	}
+	return n.entry, true
+}
+
+// Rank возвращает позицию пользователя, начиная с 1.
+func (x *Index) Rank(userID string) (int, Entry, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	target, ok := x.byID[userID]
+	if !ok {
+		return 0, Entry{}, false
+	}
+
+	rank := 0
+	cur := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for cur.next[i] != nil && !less(&target.entry, &cur.next[i].entry) {
+			rank += cur.span[i]
+			cur = cur.next[i]
+		}
+		if cur == target {
+			return rank, target.entry, true
+		}
+	}
+	return 0, Entry{}, false
+}
+
+// Range возвращает до limit записей, пропустив первые offset.
+func (x *Index) Range(offset, limit int) []Entry {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	if offset < 0 || offset >= x.length || limit <= 0 {
+		return nil
+	}
+
+	cur := x.byRank(offset + 1)
+	entries := make([]Entry, 0, min(limit, x.length-offset))
+	for ; cur != nil && len(entries) < limit; cur = cur.next[0] {
+		entries = append(entries, cur.entry)
+	}
+	return entries
+}
+
+// All возвращает весь рейтинг по порядку.
+func (x *Index) All() []Entry {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	entries := make([]Entry, 0, x.length)
+	for cur := x.head.next[0]; cur != nil; cur = cur.next[0] {
+		entries = append(entries, cur.entry)
+	}
+	return entries
+}
+
+func (x *Index) byRank(rank int) *node {
+	traversed := 0
+	cur := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for cur.next[i] != nil && traversed+cur.span[i] <= rank {
+			traversed += cur.span[i]
+			cur = cur.next[i]
+		}
+		if traversed == rank {
+			return cur
+		}
+	}
+	return nil
+}
+
+func (x *Index) randomLevel() int {
+	level := 1
+	for level < maxLevel && x.rnd.Float64() < probability {
+		level++
+	}
+	return level
+}
+
+func (x *Index) insert(e Entry) {
+	var update [maxLevel]*node
+	var rank [maxLevel]int
+
+	cur := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		if i < x.level-1 {
+			rank[i] = rank[i+1]
+		}
+		for cur.next[i] != nil && less(&cur.next[i].entry, &e) {
+			rank[i] += cur.span[i]
+			cur = cur.next[i]
+		}
+		update[i] = cur
+	}
+
+	level := x.randomLevel()
+	if level > x.level {
+		for i := x.level; i < level; i++ {
+			rank[i] = 0
+			update[i] = x.head
+			update[i].span[i] = x.length
+		}
+		x.level = level
+	}
+
+	n := &node{entry: e, next: make([]*node, level), span: make([]int, level)}
+	for i := 0; i < level; i++ {
+		n.next[i] = update[i].next[i]
+		update[i].next[i] = n
+
+		n.span[i] = update[i].span[i] - (rank[0] - rank[i])
+		update[i].span[i] = rank[0] - rank[i] + 1
+	}
+	for i := level; i < x.level; i++ {
+		update[i].span[i]++
+	}
+
+	x.byID[e.UserID] = n
+	x.length++
+}
+
+func (x *Index) delete(target *node) {
+	var update [maxLevel]*node
+
+	cur := x.head
+	for i := x.level - 1; i >= 0; i-- {
+		for cur.next[i] != nil && less(&cur.next[i].entry, &target.entry) {
+			cur = cur.next[i]
+		}
+		update[i] = cur
+	}
+
+	for i := 0; i < x.level; i++ {
+		if update[i].next[i] == target {
+			update[i].span[i] += target.span[i] - 1
+			update[i].next[i] = target.next[i]
+		} else {
+			update[i].span[i]--
+		}
+	}
+	for x.level > 1 && x.head.next[x.level-1] == nil {
+		x.level--
+	}
+
+	delete(x.byID, target.entry.UserID)
+	x.length--
+}
@@ -0,0 +1,290 @@
+package ranking
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func entry(userID string, score int, minutes int) Entry {
+	return Entry{UserID: userID, Name: "user " + userID, Score: score, ReachedAt: base.Add(time.Duration(minutes) * time.Minute)}
+}
+
+// newIndex строит индекс с фиксированным зерном, чтобы уровни узлов были воспроизводимы.
+func newIndex(seed int64, entries ...Entry) *Index {
+	x := New()
+	x.rnd = rand.New(rand.NewSource(seed))
+	for _, e := range entries {
+		x.Upsert(e)
+	}
+	return x
+}
+
+func ids(entries []Entry) []string {
+	result := make([]string, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, e.UserID)
+	}
+	return result
+}
+
+// checkIndex сверяет порядок, позиции и ширины ссылок индекса с ожидаемым рейтингом.
+func checkIndex(t *testing.T, x *Index, want []string) {
+	t.Helper()
+
+	if got := ids(x.All()); !reflect.DeepEqual(got, want) {
+		t.Fatalf("All() = %v, want %v", got, want)
+	}
+	if x.Len() != len(want) {
+		t.Fatalf("Len() = %d, want %d", x.Len(), len(want))
+	}
+	for i, id := range want {
+		rank, e, ok := x.Rank(id)
+		if !ok || rank != i+1 || e.UserID != id {
+			t.Fatalf("Rank(%s) = %d, %s, %v, want %d", id, rank, e.UserID, ok, i+1)
+		}
+		if got := ids(x.Range(i, 1)); !reflect.DeepEqual(got, []string{id}) {
+			t.Fatalf("Range(%d, 1) = %v, want [%s]", i, got, id)
+		}
+	}
+
+	// Ширина каждой ссылки — разница позиций её концов
+	position := map[*node]int{x.head: 0}
+	i := 1
+	for cur := x.head.next[0]; cur != nil; cur = cur.next[0] {
+		position[cur] = i
+		i++
+	}
+	for cur := x.head; cur != nil; cur = cur.next[0] {
+		for level := 0; level < len(cur.next) && level < x.level; level++ {
+			next := cur.next[level]
+			if next == nil {
+				continue
+			}
+			if span, want := cur.span[level], position[next]-position[cur]; span != want {
+				t.Fatalf("span of node %d at level %d = %d, want %d", position[cur], level, span, want)
+			}
+		}
+	}
+}
+
+func TestUpsert(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    []string
+	}{
+		{
+			name:    "empty",
+			entries: nil,
+			want:    []string{},
+		},
+		{
+			name:    "by score",
+			entries: []Entry{entry("a", 10, 0), entry("b", 30, 0), entry("c", 20, 0)},
+			want:    []string{"b", "c", "a"},
+		},
+		{
+			name:    "score tie broken by reached_at",
+			entries: []Entry{entry("a", 10, 5), entry("b", 10, 1), entry("c", 10, 3)},
+			want:    []string{"b", "c", "a"},
+		},
+		{
+			name:    "full tie broken by user id",
+			entries: []Entry{entry("c", 10, 0), entry("a", 10, 0), entry("b", 10, 0)},
+			want:    []string{"a", "b", "c"},
+		},
+		{
+			name:    "update moves user up",
+			entries: []Entry{entry("a", 10, 0), entry("b", 20, 0), entry("c", 30, 0), entry("a", 40, 1)},
+			want:    []string{"a", "c", "b"},
+		},
+		{
+			name:    "update moves user down",
+			entries: []Entry{entry("a", 40, 0), entry("b", 20, 0), entry("c", 30, 0), entry("a", 10, 1)},
+			want:    []string{"c", "b", "a"},
+		},
+		{
+			name:    "update into a tie",
+			entries: []Entry{entry("a", 10, 0), entry("b", 20, 2), entry("a", 20, 1)},
+			want:    []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := newIndex(1, tt.entries...)
+			checkIndex(t, x, tt.want)
+		})
+	}
+}
+
+func TestUpsertSameScoreUpdatesName(t *testing.T) {
+	x := newIndex(1, entry("a", 10, 0), entry("b", 20, 0))
+
+	renamed := entry("a", 10, 0)
+	renamed.Name = "renamed"
+	x.Upsert(renamed)
+
+	got, ok := x.Get("a")
+	if !ok || got.Name != "renamed" {
+		t.Fatalf("Get(a) = %+v, %v, want name renamed", got, ok)
+	}
+	checkIndex(t, x, []string{"b", "a"})
+}
+
+func TestRemove(t *testing.T) {
+	entries := []Entry{entry("a", 50, 0), entry("b", 40, 0), entry("c", 30, 0), entry("d", 30, 1), entry("e", 10, 0)}
+
+	tests := []struct {
+		name   string
+		remove []string
+		want   []string
+	}{
+		{name: "head", remove: []string{"a"}, want: []string{"b", "c", "d", "e"}},
+		{name: "tail", remove: []string{"e"}, want: []string{"a", "b", "c", "d"}},
+		{name: "middle of a tie", remove: []string{"c"}, want: []string{"a", "b", "d", "e"}},
+		{name: "head and tail", remove: []string{"a", "e"}, want: []string{"b", "c", "d"}},
+		{name: "missing user", remove: []string{"x"}, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "twice", remove: []string{"b", "b"}, want: []string{"a", "c", "d", "e"}},
+		{name: "all", remove: []string{"c", "a", "e", "b", "d"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := newIndex(1, entries...)
+			for _, id := range tt.remove {
+				x.Remove(id)
+			}
+			checkIndex(t, x, tt.want)
+
+			for _, id := range tt.remove {
+				if _, _, ok := x.Rank(id); ok {
+					t.Fatalf("Rank(%s) found removed user", id)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveThenInsert(t *testing.T) {
+	x := newIndex(1, entry("a", 30, 0), entry("b", 20, 0), entry("c", 10, 0))
+	x.Remove("a")
+	x.Remove("c")
+	x.Upsert(entry("d", 40, 0))
+	x.Upsert(entry("e", 5, 0))
+
+	checkIndex(t, x, []string{"d", "b", "e"})
+}
+
+func TestRank(t *testing.T) {
+	x := newIndex(1, entry("a", 30, 0), entry("b", 20, 1), entry("c", 20, 0), entry("d", 10, 0))
+
+	tests := []struct {
+		userID string
+		want   int
+		ok     bool
+	}{
+		{userID: "a", want: 1, ok: true},
+		{userID: "c", want: 2, ok: true},
+		{userID: "b", want: 3, ok: true},
+		{userID: "d", want: 4, ok: true},
+		{userID: "x", want: 0, ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			rank, e, ok := x.Rank(tt.userID)
+			if rank != tt.want || ok != tt.ok {
+				t.Fatalf("Rank(%s) = %d, %v, want %d, %v", tt.userID, rank, ok, tt.want, tt.ok)
+			}
+			if ok && e.UserID != tt.userID {
+				t.Fatalf("Rank(%s) returned entry of %s", tt.userID, e.UserID)
+			}
+		})
+	}
+}
+
+func TestRange(t *testing.T) {
+	x := newIndex(1, entry("a", 50, 0), entry("b", 40, 0), entry("c", 30, 0), entry("d", 20, 0), entry("e", 10, 0))
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []string
+	}{
+		{name: "first page", offset: 0, limit: 2, want: []string{"a", "b"}},
+		{name: "middle page", offset: 2, limit: 2, want: []string{"c", "d"}},
+		{name: "truncated last page", offset: 3, limit: 10, want: []string{"d", "e"}},
+		{name: "everything", offset: 0, limit: 5, want: []string{"a", "b", "c", "d", "e"}},
+		{name: "last entry", offset: 4, limit: 1, want: []string{"e"}},
+		{name: "offset past end", offset: 5, limit: 2, want: nil},
+		{name: "negative offset", offset: -1, limit: 2, want: nil},
+		{name: "zero limit", offset: 0, limit: 0, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := x.Range(tt.offset, tt.limit)
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("Range(%d, %d) = %v, want nil", tt.offset, tt.limit, ids(got))
+				}
+				return
+			}
+			if !reflect.DeepEqual(ids(got), tt.want) {
+				t.Fatalf("Range(%d, %d) = %v, want %v", tt.offset, tt.limit, ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestRangeEmpty(t *testing.T) {
+	if got := New().Range(0, 10); got != nil {
+		t.Fatalf("Range on empty index = %v, want nil", ids(got))
+	}
+}
+
+func TestReplace(t *testing.T) {
+	x := newIndex(1, entry("a", 10, 0), entry("b", 20, 0))
+	x.Replace([]Entry{entry("c", 5, 0), entry("d", 15, 0), entry("c", 25, 0)})
+
+	checkIndex(t, x, []string{"c", "d"})
+	if _, ok := x.Get("a"); ok {
+		t.Fatal("Get(a) found user dropped by Replace")
+	}
+}
+
+// TestRandomOperations сверяет индекс со списком, отсортированным напрямую, на длинной
+// последовательности вставок, обновлений и удалений — так задействуются высокие уровни.
+func TestRandomOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	x := newIndex(7)
+	reference := make(map[string]Entry)
+
+	for step := 0; step < 3000; step++ {
+		id := fmt.Sprintf("u%03d", rnd.Intn(300))
+		if rnd.Intn(4) == 0 {
+			x.Remove(id)
+			delete(reference, id)
+		} else {
+			e := entry(id, rnd.Intn(50), rnd.Intn(10))
+			x.Upsert(e)
+			reference[id] = e
+		}
+
+		if step%250 == 0 || step == 2999 {
+			want := make([]Entry, 0, len(reference))
+			for _, e := range reference {
+				want = append(want, e)
+			}
+			sort.Slice(want, func(i, j int) bool { return less(&want[i], &want[j]) })
+			checkIndex(t, x, ids(want))
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var ErrBadgeExists = errors.New("badge already exists")
+
+// badgeMetrics — показатель пользователя $1 для каждого правила, кроме season_rank,
+// которое проверяется по итоговой таблице сезона. Служебное задание за привязку реферера
+// заданием не считается, а рефералы — только выплаченные, чтобы значки нельзя было накрутить.
+var badgeMetrics = map[model.BadgeRule]string{
+	model.BadgeRuleTasksCompleted: `SELECT COUNT(*) FROM user_tasks
+            WHERE user_id = $1 AND task_id <> '` + referralTaskID + `' AND completed_at IS NOT NULL`,
+	model.BadgeRuleReferrals: `SELECT COUNT(*) FROM referrals WHERE referrer_id = $1 AND status = '` + string(model.ReferralPaid) + `'`,
+	// Самая длинная серия дней подряд с выполненными заданиями
+	model.BadgeRuleTaskStreak: `SELECT COALESCE(MAX(days), 0) FROM (
+            SELECT COUNT(*) AS days FROM (
+                SELECT d, d - CAST(ROW_NUMBER() OVER (ORDER BY d) AS int) AS grp
+                FROM (SELECT DISTINCT completed_at::date AS d FROM task_completions
+                      WHERE user_id = $1 AND task_id <> '` + referralTaskID + `') days
+            ) g
+            GROUP BY grp
+        ) streaks`,
+	model.BadgeRuleCheckinStreak: `SELECT COALESCE((SELECT longest FROM user_streaks WHERE user_id = $1), 0)`,
+}
+
+type BadgeRepo struct {
+	db *sql.DB
+}
+
+type BadgeRepository interface {
+	ListBadges(ctx context.Context) ([]model.Badge, error)
+	CreateBadge(ctx context.Context, badge *model.Badge) error
+	AwardEligible(ctx context.Context, userID string, rules []model.BadgeRule) ([]model.UserBadge, error)
+	AwardSeasonRanks(ctx context.Context, seasonID int64) ([]model.UserBadge, error)
+	ListUserBadges(ctx context.Context, userID string) ([]model.UserBadge, error)
+}
+
+func NewBadgeRepo(db *sql.DB) *BadgeRepo {
+	return &BadgeRepo{db: db}
+}
+
+// SupportsBadgeRule сообщает, умеет ли движок проверять правило.
+func SupportsBadgeRule(rule model.BadgeRule) bool {
+	_, ok := badgeMetrics[rule]
+	return ok || rule == model.BadgeRuleSeasonRank
+}
+
+func (r *BadgeRepo) ListBadges(ctx context.Context) ([]model.Badge, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, description, rule, threshold FROM badges ORDER BY rule, threshold, id`)
+	if err != nil {
+		return nil, fmt.Errorf("query badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []model.Badge{}
+	for rows.Next() {
+		var badge model.Badge
+		if err := rows.Scan(&badge.ID, &badge.Name, &badge.Description, &badge.Rule, &badge.Threshold); err != nil {
+			return nil, fmt.Errorf("scan badge: %w", err)
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return badges, nil
+}
+
+func (r *BadgeRepo) CreateBadge(ctx context.Context, badge *model.Badge) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO badges (id, name, description, rule, threshold, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		badge.ID, badge.Name, badge.Description, badge.Rule, badge.Threshold, time.Now())
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return ErrBadgeExists
+		}
+		return fmt.Errorf("create badge: %w", err)
+	}
+	return nil
+}
+
+// AwardEligible выдаёт пользователю ещё не полученные значки по правилам rules,
+// показатель которых достиг порога, и возвращает выданные.
+func (r *BadgeRepo) AwardEligible(ctx context.Context, userID string, rules []model.BadgeRule) ([]model.UserBadge, error) {
+	now := time.Now()
+	var awarded []model.UserBadge
+	for _, rule := range rules {
+		metric, ok := badgeMetrics[rule]
+		if !ok {
+			return nil, fmt.Errorf("unsupported badge rule %q", rule)
+		}
+
+		badges, err := r.award(ctx,
+			`INSERT INTO user_badges (user_id, badge_id, awarded_at)
+             SELECT $1, b.id, $3 FROM badges b
+             WHERE b.rule = $2 AND b.threshold <= (`+metric+`)
+             ON CONFLICT (user_id, badge_id) DO NOTHING
+             RETURNING user_id, badge_id, awarded_at`,
+			userID, rule, now)
+		if err != nil {
+			return nil, err
+		}
+		awarded = append(awarded, badges...)
+	}
+	return awarded, nil
+}
+
+// AwardSeasonRanks выдаёт значки season_rank участникам итоговой таблицы сезона.
+func (r *BadgeRepo) AwardSeasonRanks(ctx context.Context, seasonID int64) ([]model.UserBadge, error) {
+	return r.award(ctx,
+		`INSERT INTO user_badges (user_id, badge_id, awarded_at)
+         SELECT s.user_id, b.id, $3 FROM season_snapshots s
+         JOIN badges b ON b.rule = $2 AND s.position <= b.threshold
+         WHERE s.season_id = $1
+         ON CONFLICT (user_id, badge_id) DO NOTHING
+         RETURNING user_id, badge_id, awarded_at`,
+		seasonID, model.BadgeRuleSeasonRank, time.Now())
+}
+
+func (r *BadgeRepo) award(ctx context.Context, query string, args ...any) ([]model.UserBadge, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`WITH awarded AS (`+query+`)
+         SELECT a.user_id, a.badge_id, b.name, b.description, a.awarded_at
+         FROM awarded a JOIN badges b ON b.id = a.badge_id`,
+		args...)
+	if err != nil {
+		return nil, fmt.Errorf("award badges: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUserBadges(rows)
+}
+
+func (r *BadgeRepo) ListUserBadges(ctx context.Context, userID string) ([]model.UserBadge, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT ub.user_id, ub.badge_id, b.name, b.description, ub.awarded_at
+         FROM user_badges ub
+         JOIN badges b ON b.id = ub.badge_id
+         WHERE ub.user_id = $1
+         ORDER BY ub.awarded_at, ub.badge_id`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("query user badges: %w", err)
+	}
+	defer rows.Close()
+
+	return scanUserBadges(rows)
+}
+
+func scanUserBadges(rows *sql.Rows) ([]model.UserBadge, error) {
+	badges := []model.UserBadge{}
+	for rows.Next() {
+		var badge model.UserBadge
+		if err := rows.Scan(&badge.UserID, &badge.BadgeID, &badge.Name, &badge.Description, &badge.AwardedAt); err != nil {
+			return nil, fmt.Errorf("scan user badge: %w", err)
+		}
+		badges = append(badges, badge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return badges, nil
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrAlreadyCheckedIn = errors.New("already checked in today")
+	ErrFreezeLimit      = errors.New("streak freeze limit reached")
+)
+
+type CheckinRepo struct {
+	db *sql.DB
+}
+
+type CheckinRepository interface {
+	GetStreak(ctx context.Context, userID string) (*model.Streak, error)
+	CheckIn(ctx context.Context, userID, timezone string, today func(timezone string) time.Time, reward func(streak int) int) (*model.CheckIn, error)
+	BuyFreeze(ctx context.Context, userID, timezone string, cost, maxFreezes int) (*model.FreezePurchase, error)
+}
+
+func NewCheckinRepo(db *sql.DB) *CheckinRepo {
+	return &CheckinRepo{db: db}
+}
+
+// GetStreak возвращает серию пользователя; nil — пользователь ещё не отмечался.
+func (r *CheckinRepo) GetStreak(ctx context.Context, userID string) (*model.Streak, error) {
+	streak, err := scanStreak(r.db.QueryRowContext(ctx,
+		`SELECT current, longest, last_checkin, freezes, timezone FROM user_streaks WHERE user_id = $1`, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return streak, err
+}
+
+func scanStreak(row *sql.Row) (*model.Streak, error) {
+	var streak model.Streak
+	var last sql.NullTime
+	if err := row.Scan(&streak.Current, &streak.Longest, &last, &streak.Freezes, &streak.Timezone); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("get streak: %w", err)
+	}
+	if last.Valid {
+		streak.LastCheckin = &last.Time
+	}
+	return &streak, nil
+}
+
+// lockStreak создаёт при необходимости и блокирует строку серии пользователя.
+func lockStreak(ctx context.Context, tx *sql.Tx, userID, timezone string) (*model.Streak, error) {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO user_streaks (user_id, timezone) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`,
+		userID, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("create streak: %w", err)
+	}
+	return scanStreak(tx.QueryRowContext(ctx,
+		`SELECT current, longest, last_checkin, freezes, timezone FROM user_streaks WHERE user_id = $1 FOR UPDATE`,
+		userID))
+}
+
+// CheckIn отмечает пользователя за день today(пояс) и начисляет reward(длина серии) очков.
+// Пояс timezone принимается только при первой отметке, дальше действует сохранённый.
+// Пропущенные дни покрываются заморозками, если их хватает на весь пропуск;
+// иначе серия начинается заново.
+func (r *CheckinRepo) CheckIn(ctx context.Context, userID, timezone string, today func(timezone string) time.Time, reward func(streak int) int) (*model.CheckIn, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	streak, err := lockStreak(ctx, tx, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Смена пояса между отметками дала бы лишние «дни» в одних реальных сутках
+	if streak.LastCheckin != nil {
+		timezone = streak.Timezone
+	}
+	day := today(timezone)
+
+	checkin := &model.CheckIn{Day: day.Format(time.DateOnly), Timezone: timezone, Streak: 1}
+	if streak.LastCheckin != nil {
+		missed := daysBetween(*streak.LastCheckin, day) - 1
+		switch {
+		case missed < 0:
+			return nil, ErrAlreadyCheckedIn
+		case missed <= streak.Freezes:
+			checkin.Streak = streak.Current + 1
+			checkin.FreezesUsed = missed
+		}
+	}
+	checkin.Reward = reward(checkin.Streak)
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE user_streaks
+         SET current = $2, longest = GREATEST(longest, $2), last_checkin = $3::date,
+             freezes = freezes - $4, timezone = $5
+         WHERE user_id = $1`,
+		userID, checkin.Streak, checkin.Day, checkin.FreezesUsed, timezone)
+	if err != nil {
+		return nil, fmt.Errorf("update streak: %w", err)
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO checkins (user_id, day, streak, points, freezes_used, created_at)
+         VALUES ($1, $2::date, $3, $4, $5, $6)`,
+		userID, checkin.Day, checkin.Streak, checkin.Reward, checkin.FreezesUsed, now)
+	if err != nil {
+		return nil, fmt.Errorf("record checkin: %w", err)
+	}
+
+	if checkin.Reward > 0 {
+		change, err := applyPoints(ctx, tx, userID, checkin.Reward, model.LedgerCheckin, checkin.Day, now)
+		if err != nil {
+			return nil, err
+		}
+		checkin.Change = *change
+		checkin.Balance = change.Balance
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return checkin, nil
+}
+
+// BuyFreeze покупает за cost очков одну заморозку серии, если их меньше maxFreezes.
+func (r *CheckinRepo) BuyFreeze(ctx context.Context, userID, timezone string, cost, maxFreezes int) (*model.FreezePurchase, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	streak, err := lockStreak(ctx, tx, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	if streak.Freezes >= maxFreezes {
+		return nil, ErrFreezeLimit
+	}
+
+	change, err := spendPoints(ctx, tx, userID, cost, model.LedgerStreakFreeze, "", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	purchase := &model.FreezePurchase{Balance: change.Balance, Change: *change}
+	err = tx.QueryRowContext(ctx,
+		`UPDATE user_streaks SET freezes = freezes + 1 WHERE user_id = $1 RETURNING freezes`,
+		userID).Scan(&purchase.Freezes)
+	if err != nil {
+		return nil, fmt.Errorf("add streak freeze: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return purchase, nil
+}
+
+// daysBetween считает календарные дни между датами, которые хранятся как полночь UTC.
+func daysBetween(from, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var ErrCommunityGoalNotFound = errors.New("community goal not found")
+
+type CommunityRepo struct {
+	db *sql.DB
+}
+
+type CommunityRepository interface {
+	CreateGoal(ctx context.Context, goal *model.CommunityGoal) error
+	GetGoal(ctx context.Context, id int64) (*model.CommunityGoal, error)
+	ListGoals(ctx context.Context, limit, offset int) ([]model.CommunityGoal, error)
+	CountGoals(ctx context.Context) (int, error)
+	ListRunning(ctx context.Context, now time.Time) ([]model.CommunityGoal, error)
+	MarkReached(ctx context.Context, now time.Time) ([]int64, error)
+	GetPayableGoals(ctx context.Context) ([]int64, error)
+	PayoutBatch(ctx context.Context, goalID int64, batchSize int) ([]model.BalanceChange, bool, error)
+}
+
+func NewCommunityRepo(db *sql.DB) *CommunityRepo {
+	return &CommunityRepo{db: db}
+}
+
+// Прогресс цели g: все выполнения её задания в окне цели, включая повторные
+const goalProgressExpr = `(
+    SELECT COUNT(*) FROM task_completions tc
+    WHERE tc.task_id = g.task_id AND tc.completed_at >= g.starts_at AND tc.completed_at < g.ends_at
+)`
+
+const goalSelect = `SELECT g.id, g.title, t.name, g.goal, ` + goalProgressExpr + `, g.reward_points,
+       g.starts_at, g.ends_at, g.reached_at, g.rewarded_at, g.created_at
+FROM community_goals g
+JOIN tasks t ON t.id = g.task_id`
+
+func scanGoal(row interface{ Scan(...any) error }) (*model.CommunityGoal, error) {
+	var goal model.CommunityGoal
+	var reachedAt, rewardedAt sql.NullTime
+	if err := row.Scan(&goal.ID, &goal.Title, &goal.Task, &goal.Goal, &goal.Progress, &goal.RewardPoints,
+		&goal.StartsAt, &goal.EndsAt, &reachedAt, &rewardedAt, &goal.CreatedAt); err != nil {
+		return nil, err
+	}
+	if reachedAt.Valid {
+		goal.ReachedAt = &reachedAt.Time
+	}
+	if rewardedAt.Valid {
+		goal.RewardedAt = &rewardedAt.Time
+	}
+	return &goal, nil
+}
+
+func (r *CommunityRepo) queryGoals(ctx context.Context, query string, args ...any) ([]model.CommunityGoal, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query community goals: %w", err)
+	}
+	defer rows.Close()
+
+	goals := []model.CommunityGoal{}
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan community goal: %w", err)
+		}
+		goals = append(goals, *goal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return goals, nil
+}
+
+func (r *CommunityRepo) CreateGoal(ctx context.Context, goal *model.CommunityGoal) error {
+	var taskID string
+	err := r.db.QueryRowContext(ctx, `SELECT id FROM tasks WHERE name = $1`, goal.Task).Scan(&taskID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTaskNotFound
+		}
+		return fmt.Errorf("find goal task: %w", err)
+	}
+
+	goal.CreatedAt = time.Now()
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO community_goals (title, task_id, goal, reward_points, starts_at, ends_at, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		goal.Title, taskID, goal.Goal, goal.RewardPoints, goal.StartsAt, goal.EndsAt, goal.CreatedAt).Scan(&goal.ID)
+	if err != nil {
+		return fmt.Errorf("create community goal: %w", err)
+	}
+	return nil
+}
+
+func (r *CommunityRepo) GetGoal(ctx context.Context, id int64) (*model.CommunityGoal, error) {
+	goal, err := scanGoal(r.db.QueryRowContext(ctx, goalSelect+` WHERE g.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCommunityGoalNotFound
+		}
+		return nil, fmt.Errorf("get community goal: %w", err)
+	}
+	return goal, nil
+}
+
+// ListGoals возвращает цели, позже заканчивающиеся первыми.
+func (r *CommunityRepo) ListGoals(ctx context.Context, limit, offset int) ([]model.CommunityGoal, error) {
+	return r.queryGoals(ctx, goalSelect+` ORDER BY g.ends_at DESC, g.id DESC LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+func (r *CommunityRepo) CountGoals(ctx context.Context) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM community_goals`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count community goals: %w", err)
+	}
+	return total, nil
+}
+
+// ListRunning возвращает цели, окно которых идёт сейчас.
+func (r *CommunityRepo) ListRunning(ctx context.Context, now time.Time) ([]model.CommunityGoal, error) {
+	return r.queryGoals(ctx, goalSelect+` WHERE g.starts_at <= $1 AND g.ends_at > $1 ORDER BY g.id`, now)
+}
+
+// MarkReached отмечает достигнутыми цели, прогресс которых дошёл до цели, и возвращает их ID.
+// Прогресс считается только по окну цели, поэтому закончившиеся цели тоже проверяются:
+// цель, достигнутую, пока фоновые задачи не работали, отметят при следующем запуске.
+func (r *CommunityRepo) MarkReached(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE community_goals g SET reached_at = $1
+         WHERE g.reached_at IS NULL AND g.starts_at <= $1
+           AND `+goalProgressExpr+` >= g.goal
+         RETURNING g.id`,
+		now)
+	if err != nil {
+		return nil, fmt.Errorf("mark community goals reached: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan community goal id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+// GetPayableGoals возвращает достигнутые цели, награда по которым выплачена ещё не всем.
+func (r *CommunityRepo) GetPayableGoals(ctx context.Context) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM community_goals WHERE reached_at IS NOT NULL AND rewarded_at IS NULL ORDER BY reached_at`)
+	if err != nil {
+		return nil, fmt.Errorf("query payable community goals: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan community goal id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+// PayoutBatch начисляет награду следующим batchSize участникам, которым она ещё не выплачена.
+// Выполнившие задание после достижения цели, но до конца окна, тоже награждаются; цель
+// считается выплаченной (true), когда окно закончилось и неоплаченных участников не осталось.
+// Строка цели блокируется, поэтому параллельные запуски не платят дважды.
+func (r *CommunityRepo) PayoutBatch(ctx context.Context, goalID int64, batchSize int) ([]model.BalanceChange, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var taskID string
+	var reward int
+	var startsAt, endsAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT task_id, reward_points, starts_at, ends_at FROM community_goals
+         WHERE id = $1 AND rewarded_at IS NULL FOR UPDATE`,
+		goalID).Scan(&taskID, &reward, &startsAt, &endsAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, true, nil
+		}
+		return nil, false, fmt.Errorf("lock community goal: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT tc.user_id FROM task_completions tc
+         WHERE tc.task_id = $1 AND tc.completed_at >= $2 AND tc.completed_at < $3
+           AND NOT EXISTS(SELECT 1 FROM community_goal_payouts p WHERE p.goal_id = $4 AND p.user_id = tc.user_id)
+         GROUP BY tc.user_id
+         ORDER BY MIN(tc.completed_at), tc.user_id
+         LIMIT $5`,
+		taskID, startsAt, endsAt, goalID, batchSize)
+	if err != nil {
+		return nil, false, fmt.Errorf("query community goal participants: %w", err)
+	}
+	var participants []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, false, fmt.Errorf("scan community goal participant: %w", err)
+		}
+		participants = append(participants, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("rows error: %w", err)
+	}
+
+	now := time.Now()
+	reference := strconv.FormatInt(goalID, 10)
+	changes := make([]model.BalanceChange, 0, len(participants))
+	for _, userID := range participants {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO community_goal_payouts (goal_id, user_id, paid_at) VALUES ($1, $2, $3)`, goalID, userID, now)
+		if err != nil {
+			return nil, false, fmt.Errorf("record community goal payout: %w", err)
+		}
+		change, err := applyPoints(ctx, tx, userID, reward, model.LedgerCommunityGoal, reference, now)
+		if err != nil {
+			return nil, false, fmt.Errorf("award community goal: %w", err)
+		}
+		changes = append(changes, *change)
+	}
+
+	finished := len(participants) < batchSize && !endsAt.After(now)
+	if finished {
+		if _, err := tx.ExecContext(ctx, `UPDATE community_goals SET rewarded_at = $2 WHERE id = $1`, goalID, now); err != nil {
+			return nil, false, fmt.Errorf("mark community goal rewarded: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("commit transaction: %w", err)
+	}
+	return changes, finished, nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type ExpiryRepo struct {
+	db *sql.DB
+}
+
+type ExpiryRepository interface {
+	GetUsersWithExpired(ctx context.Context, cutoff time.Time, after string, limit int) ([]string, error)
+	ExpireUserPoints(ctx context.Context, userID string, cutoff time.Time) (*model.BalanceChange, error)
+	GetExpiring(ctx context.Context, userID string, from, until time.Time, months int) ([]model.ExpiringPoints, error)
+}
+
+func NewExpiryRepo(db *sql.DB) *ExpiryRepo {
+	return &ExpiryRepo{db: db}
+}
+
+// GetUsersWithExpired возвращает пользователей с ID больше after, у которых есть партии,
+// начисленные раньше cutoff, по возрастанию ID.
+func (r *ExpiryRepo) GetUsersWithExpired(ctx context.Context, cutoff time.Time, after string, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT user_id FROM point_lots
+         WHERE remaining > 0 AND earned_at < $1 AND user_id > $2
+         ORDER BY user_id
+         LIMIT $3`,
+		cutoff, after, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query users with expired points: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ExpireUserPoints списывает остаток партий, начисленных раньше cutoff, с записью
+// points_expired в журнале. Просроченные партии — самые старые, поэтому обычное
+// FIFO-списание расходует именно их. Возвращает nil, если сгорать нечему.
+func (r *ExpiryRepo) ExpireUserPoints(ctx context.Context, userID string, cutoff time.Time) (*model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var balance int
+	err = tx.QueryRowContext(ctx, `SELECT points FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&balance)
+	if err != nil {
+		return nil, fmt.Errorf("lock user: %w", err)
+	}
+
+	var expired int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(remaining), 0) FROM point_lots
+         WHERE user_id = $1 AND remaining > 0 AND earned_at < $2`,
+		userID, cutoff).Scan(&expired)
+	if err != nil {
+		return nil, fmt.Errorf("sum expired lots: %w", err)
+	}
+
+	// Баланс не уходит в минус, даже если партии разошлись с ним
+	var change *model.BalanceChange
+	if amount := min(expired, balance); amount > 0 {
+		change, err = spendPoints(ctx, tx, userID, amount, model.LedgerPointsExpired, "", time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE point_lots SET remaining = 0
+         WHERE user_id = $1 AND remaining > 0 AND earned_at < $2`,
+		userID, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("close expired lots: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return change, nil
+}
+
+// GetExpiring суммирует по дням остаток партий, начисленных в [from, until),
+// то есть сгорающих в ближайшее время.
+func (r *ExpiryRepo) GetExpiring(ctx context.Context, userID string, from, until time.Time, months int) ([]model.ExpiringPoints, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT (earned_at + make_interval(months => $4))::date AS expires_on, SUM(remaining)
+         FROM point_lots
+         WHERE user_id = $1 AND remaining > 0 AND earned_at >= $2 AND earned_at < $3
+         GROUP BY expires_on
+         ORDER BY expires_on`,
+		userID, from, until, months)
+	if err != nil {
+		return nil, fmt.Errorf("query expiring points: %w", err)
+	}
+	defer rows.Close()
+
+	var expiring []model.ExpiringPoints
+	for rows.Next() {
+		var day time.Time
+		var p model.ExpiringPoints
+		if err := rows.Scan(&day, &p.Amount); err != nil {
+			return nil, fmt.Errorf("scan expiring points: %w", err)
+		}
+		p.ExpiresOn = day.Format(time.DateOnly)
+		expiring = append(expiring, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return expiring, nil
+}
@@ -0,0 +1,243 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ErrInsufficientPoints — на балансе не хватает очков для списания.
+var ErrInsufficientPoints = errors.New("insufficient points")
+
+// earningReasons — начисления, которые считаются заработком: по ним строятся рейтинги
+// за период и очки команд. Переводы, возвраты, стартовый баланс и ручные корректировки
+// заработком не считаются, иначе их можно было бы накручивать.
+var earningReasons = []model.LedgerReason{
+	model.LedgerTask,
+	model.LedgerReferralReward,
+	model.LedgerReferralCommission,
+	model.LedgerSeasonReward,
+	model.LedgerPromoCode,
+	model.LedgerCheckin,
+	model.LedgerWheelPrize,
+	model.LedgerRafflePrize,
+	model.LedgerTeamChallenge,
+	model.LedgerCommunityGoal,
+}
+
+// rankingReasons — заработок для рейтингов за период и сезонов. Сезонная награда
+// выплачивается в момент фиксации итогов, то есть уже в окне следующего сезона,
+// и не должна давать прошлым победителям фору.
+var rankingReasons = reasonsArray(earningReasons, model.LedgerSeasonReward)
+
+// reasonsArray — параметр запроса для reason = ANY(...) из reasons без except.
+func reasonsArray(reasons []model.LedgerReason, except ...model.LedgerReason) any {
+	values := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		if !slices.Contains(except, reason) {
+			values = append(values, string(reason))
+		}
+	}
+	return pq.Array(values)
+}
+
+// querier — общий интерфейс *sql.DB и *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// applyPoints меняет баланс пользователя и записывает операцию в журнал.
+// Все изменения баланса должны проходить через эту функцию, spendPoints или restorePoints.
+func applyPoints(ctx context.Context, db querier, userID string, amount int, reason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	change, err := changePoints(ctx, db, userID, amount, reason, referenceID, at)
+	if err != nil {
+		return nil, err
+	}
+	if amount > 0 {
+		err = addLot(ctx, db, userID, amount, reason, at)
+	} else {
+		err = consumeLots(ctx, db, userID, -amount, reason, referenceID, at)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// restorePoints начисляет очки, списанные ранее операцией spentReason с тем же referenceID:
+// возврат за заказ или входящий перевод. Партии восстанавливаются с исходными датами
+// начисления, поэтому возврат и перевод не продлевают срок сгорания.
+func restorePoints(ctx context.Context, db querier, userID string, amount int, reason, spentReason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	change, err := changePoints(ctx, db, userID, amount, reason, referenceID, at)
+	if err != nil {
+		return nil, err
+	}
+	if err := restoreLots(ctx, db, userID, amount, reason, spentReason, referenceID, at); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// changePoints меняет баланс и пишет журнал, не трогая партии.
+func changePoints(ctx context.Context, db querier, userID string, amount int, reason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	change := &model.BalanceChange{UserID: userID, Currency: model.CurrencyPoints, Delta: amount, Reason: reason}
+	err := db.QueryRowContext(ctx,
+		`UPDATE users SET points = points + $1, updated_at = $2, points_reached_at = $2 WHERE id = $3
+         RETURNING points`,
+		amount, at, userID).Scan(&change.Balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s not found", userID)
+		}
+		return nil, fmt.Errorf("failed to update user points: %w", err)
+	}
+
+	if err := writeLedger(ctx, db, userID, model.CurrencyPoints, amount, reason, referenceID, at); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// spendPoints списывает cost очков, только если их хватает. Проверка и списание —
+// один UPDATE, поэтому параллельные списания не уводят баланс в минус.
+func spendPoints(ctx context.Context, db querier, userID string, cost int, reason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	change := &model.BalanceChange{UserID: userID, Currency: model.CurrencyPoints, Delta: -cost, Reason: reason}
+	err := db.QueryRowContext(ctx,
+		`UPDATE users SET points = points - $1, updated_at = $2, points_reached_at = $2
+         WHERE id = $3 AND points >= $1
+         RETURNING points`,
+		cost, at, userID).Scan(&change.Balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInsufficientPoints
+		}
+		return nil, fmt.Errorf("failed to spend user points: %w", err)
+	}
+
+	if err := writeLedger(ctx, db, userID, model.CurrencyPoints, -cost, reason, referenceID, at); err != nil {
+		return nil, err
+	}
+	if err := consumeLots(ctx, db, userID, cost, reason, referenceID, at); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// awardXP начисляет опыт. Опыт не тратится и не сгорает, поэтому для него нет
+// ни списаний, ни партий.
+func awardXP(ctx context.Context, db querier, userID string, amount int, reason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("xp amount must be positive, got %d", amount)
+	}
+
+	change := &model.BalanceChange{UserID: userID, Currency: model.CurrencyXP, Delta: amount, Reason: reason}
+	err := db.QueryRowContext(ctx,
+		`UPDATE users SET xp = xp + $1, updated_at = $2, xp_reached_at = $2 WHERE id = $3
+         RETURNING xp`,
+		amount, at, userID).Scan(&change.Balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("user %s not found", userID)
+		}
+		return nil, fmt.Errorf("failed to update user xp: %w", err)
+	}
+
+	if err := writeLedger(ctx, db, userID, model.CurrencyXP, amount, reason, referenceID, at); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// award начисляет amount в указанной валюте.
+func award(ctx context.Context, db querier, userID string, currency model.Currency, amount int, reason model.LedgerReason, referenceID string, at time.Time) (*model.BalanceChange, error) {
+	switch currency {
+	case model.CurrencyPoints:
+		return applyPoints(ctx, db, userID, amount, reason, referenceID, at)
+	case model.CurrencyXP:
+		return awardXP(ctx, db, userID, amount, reason, referenceID, at)
+	default:
+		return nil, fmt.Errorf("unknown currency %q", currency)
+	}
+}
+
+func writeLedger(ctx context.Context, db querier, userID string, currency model.Currency, amount int, reason model.LedgerReason, referenceID string, at time.Time) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO points_ledger (user_id, amount, reason, reference_id, created_at, currency)
+         VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)`,
+		userID, amount, reason, referenceID, at, currency)
+	if err != nil {
+		return fmt.Errorf("failed to write ledger entry: %w", err)
+	}
+	return nil
+}
+
+// addLot заводит партию начисленных очков, от которой отсчитывается срок сгорания.
+func addLot(ctx context.Context, db querier, userID string, amount int, reason model.LedgerReason, at time.Time) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO point_lots (user_id, amount, remaining, reason, earned_at) VALUES ($1, $2, $2, $3, $4)`,
+		userID, amount, reason, at)
+	if err != nil {
+		return fmt.Errorf("failed to add point lot: %w", err)
+	}
+	return nil
+}
+
+// consumeLots списывает amount очков с самых старых партий (FIFO) и запоминает в point_lot_spends,
+// сколько взято из каждой партии. Вызывается после UPDATE users, поэтому строка пользователя
+// уже заблокирована и партии не меняются параллельно.
+func consumeLots(ctx context.Context, db querier, userID string, amount int, reason model.LedgerReason, referenceID string, at time.Time) error {
+	if amount <= 0 {
+		return nil
+	}
+	_, err := db.ExecContext(ctx,
+		`WITH consumed AS (
+             UPDATE point_lots l
+             SET remaining = l.remaining - LEAST(l.remaining, $2 - o.before)
+             FROM (
+                 SELECT id, remaining, SUM(remaining) OVER (ORDER BY earned_at, id) - remaining AS before
+                 FROM point_lots
+                 WHERE user_id = $1 AND remaining > 0
+             ) o
+             WHERE l.id = o.id AND o.before < $2
+             RETURNING l.earned_at, LEAST(o.remaining, $2 - o.before) AS amount
+         )
+         INSERT INTO point_lot_spends (user_id, reason, reference_id, amount, earned_at, spent_at)
+         SELECT $1, $3, NULLIF($4, ''), amount, earned_at, $5 FROM consumed WHERE amount > 0`,
+		userID, amount, reason, referenceID, at)
+	if err != nil {
+		return fmt.Errorf("failed to consume point lots: %w", err)
+	}
+	return nil
+}
+
+// restoreLots заводит партии получателя по записям point_lot_spends списания spentReason
+// с тем же referenceID, сохраняя даты начисления. Если записей меньше amount (списание
+// было до их появления), остаток образует новую партию.
+func restoreLots(ctx context.Context, db querier, userID string, amount int, reason, spentReason model.LedgerReason, referenceID string, at time.Time) error {
+	var restored int
+	err := db.QueryRowContext(ctx,
+		`WITH restored AS (
+             INSERT INTO point_lots (user_id, amount, remaining, reason, earned_at)
+             SELECT $1, SUM(amount), SUM(amount), $2, earned_at
+             FROM point_lot_spends
+             WHERE reason = $3 AND reference_id = $4
+             GROUP BY earned_at
+             RETURNING amount
+         )
+         SELECT COALESCE(SUM(amount), 0) FROM restored`,
+		userID, reason, spentReason, referenceID).Scan(&restored)
+	if err != nil {
+		return fmt.Errorf("failed to restore point lots: %w", err)
+	}
+	if restored < amount {
+		return addLot(ctx, db, userID, amount-restored, reason, at)
+	}
+	return nil
+}
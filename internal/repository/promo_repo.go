@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrPromoNotFound      = errors.New("promo code not found")
+	ErrPromoNotActive     = errors.New("promo code is not active")
+	ErrPromoExhausted     = errors.New("promo code has been used up")
+	ErrPromoUserLimit     = errors.New("promo code limit per user reached")
+	ErrPromoCodeExists    = errors.New("promo code already exists")
+	ErrPromoBatchNotFound = errors.New("promo batch not found")
+)
+
+type PromoRepo struct {
+	db *sql.DB
+}
+
+type PromoRepository interface {
+	CreateBatch(ctx context.Context, batch *model.PromoBatch, codes []string) error
+	ListBatches(ctx context.Context, limit, offset int) ([]model.PromoBatch, error)
+	CountBatches(ctx context.Context) (int, error)
+	ListCodes(ctx context.Context, batchID int64) ([]model.PromoCode, error)
+	Redeem(ctx context.Context, userID, code string) (*model.BalanceChange, error)
+}
+
+func NewPromoRepo(db *sql.DB) *PromoRepo {
+	return &PromoRepo{db: db}
+}
+
+// CreateBatch сохраняет партию вместе с кодами. Если хотя бы один код уже занят,
+// ничего не сохраняется и возвращается ErrPromoCodeExists.
+func (r *PromoRepo) CreateBatch(ctx context.Context, batch *model.PromoBatch, codes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO promo_batches (name, points, max_uses, max_uses_per_user, valid_from, valid_until, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`,
+		batch.Name, batch.Points, batch.MaxUses, batch.MaxUsesPerUser, batch.ValidFrom, batch.ValidUntil, now,
+	).Scan(&batch.ID)
+	if err != nil {
+		return fmt.Errorf("create promo batch: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO promo_codes (code, batch_id, created_at)
+         SELECT code, $2, $3 FROM unnest($1::text[]) AS code
+         ON CONFLICT (code) DO NOTHING`,
+		pq.Array(codes), batch.ID, now)
+	if err != nil {
+		return fmt.Errorf("create promo codes: %w", err)
+	}
+
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if int(inserted) != len(codes) {
+		return ErrPromoCodeExists
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	batch.Codes = len(codes)
+	batch.CreatedAt = now
+	return nil
+}
+
+func (r *PromoRepo) ListBatches(ctx context.Context, limit, offset int) ([]model.PromoBatch, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT b.id, b.name, b.points, b.max_uses, b.max_uses_per_user, b.valid_from, b.valid_until, b.created_at,
+                (SELECT COUNT(*) FROM promo_codes c WHERE c.batch_id = b.id),
+                (SELECT COUNT(*) FROM promo_redemptions pr WHERE pr.batch_id = b.id)
+         FROM promo_batches b
+         ORDER BY b.created_at DESC, b.id DESC
+         LIMIT $1 OFFSET $2`,
+		limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query promo batches: %w", err)
+	}
+	defer rows.Close()
+
+	batches := []model.PromoBatch{}
+	for rows.Next() {
+		var batch model.PromoBatch
+		var maxUses sql.NullInt64
+		var validFrom, validUntil sql.NullTime
+		if err := rows.Scan(&batch.ID, &batch.Name, &batch.Points, &maxUses, &batch.MaxUsesPerUser,
+			&validFrom, &validUntil, &batch.CreatedAt, &batch.Codes, &batch.Redemptions); err != nil {
+			return nil, fmt.Errorf("scan promo batch: %w", err)
+		}
+		if maxUses.Valid {
+			v := int(maxUses.Int64)
+			batch.MaxUses = &v
+		}
+		if validFrom.Valid {
+			batch.ValidFrom = &validFrom.Time
+		}
+		if validUntil.Valid {
+			batch.ValidUntil = &validUntil.Time
+		}
+		batches = append(batches, batch)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return batches, nil
+}
+
+func (r *PromoRepo) CountBatches(ctx context.Context) (int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM promo_batches`).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count promo batches: %w", err)
+	}
+	return total, nil
+}
+
+func (r *PromoRepo) ListCodes(ctx context.Context, batchID int64) ([]model.PromoCode, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM promo_batches WHERE id = $1)`, batchID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("check promo batch: %w", err)
+	}
+	if !exists {
+		return nil, ErrPromoBatchNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT code, uses FROM promo_codes WHERE batch_id = $1 ORDER BY code`, batchID)
+	if err != nil {
+		return nil, fmt.Errorf("query promo codes: %w", err)
+	}
+	defer rows.Close()
+
+	codes := []model.PromoCode{}
+	for rows.Next() {
+		var code model.PromoCode
+		if err := rows.Scan(&code.Code, &code.Uses); err != nil {
+			return nil, fmt.Errorf("scan promo code: %w", err)
+		}
+		codes = append(codes, code)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return codes, nil
+}
+
+// Redeem активирует код и начисляет очки. Строка кода блокируется, чтобы счётчик
+// использований не превысил max_uses, а строка пользователя — чтобы параллельные
+// активации разных кодов одной партии не обошли лимит на пользователя.
+func (r *PromoRepo) Redeem(ctx context.Context, userID, code string) (*model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, fmt.Errorf("lock user: %w", err)
+	}
+
+	now := time.Now()
+	var batchID int64
+	var points, uses, maxUsesPerUser int
+	var maxUses sql.NullInt64
+	var active bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT c.batch_id, c.uses, b.points, b.max_uses, b.max_uses_per_user,
+                (b.valid_from IS NULL OR b.valid_from <= $2) AND (b.valid_until IS NULL OR b.valid_until > $2)
+         FROM promo_codes c
+         JOIN promo_batches b ON b.id = c.batch_id
+         WHERE c.code = $1
+         FOR UPDATE OF c`,
+		code, now).Scan(&batchID, &uses, &points, &maxUses, &maxUsesPerUser, &active)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPromoNotFound
+		}
+		return nil, fmt.Errorf("lock promo code: %w", err)
+	}
+	if !active {
+		return nil, ErrPromoNotActive
+	}
+	if maxUses.Valid && int64(uses) >= maxUses.Int64 {
+		return nil, ErrPromoExhausted
+	}
+
+	var userUses int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM promo_redemptions WHERE batch_id = $1 AND user_id = $2`,
+		batchID, userID).Scan(&userUses)
+	if err != nil {
+		return nil, fmt.Errorf("count user promo redemptions: %w", err)
+	}
+	if userUses >= maxUsesPerUser {
+		return nil, ErrPromoUserLimit
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE promo_codes SET uses = uses + 1 WHERE code = $1`, code); err != nil {
+		return nil, fmt.Errorf("increment promo code uses: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO promo_redemptions (code, batch_id, user_id, points, created_at)
+         VALUES ($1, $2, $3, $4, $5)`,
+		code, batchID, userID, points, now)
+	if err != nil {
+		return nil, fmt.Errorf("record promo redemption: %w", err)
+	}
+
+	change, err := applyPoints(ctx, tx, userID, points, model.LedgerPromoCode, code, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return change, nil
+}
@@ -0,0 +1,438 @@
+package repository
+
+import (
+	"Test/internal/fairness"
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrRaffleNotFound    = errors.New("raffle not found")
+	ErrRaffleClosed      = errors.New("raffle is not selling tickets")
+	ErrRaffleNotOpen     = errors.New("raffle is already drawn or cancelled")
+	ErrRaffleTicketLimit = errors.New("ticket limit reached for this raffle")
+)
+
+type RaffleRepo struct {
+	db *sql.DB
+}
+
+type RaffleRepository interface {
+	CreateRaffle(ctx context.Context, raffle *model.Raffle, serverSeed string) error
+	GetRaffle(ctx context.Context, id int64) (*model.Raffle, error)
+	ListRaffles(ctx context.Context, status model.RaffleStatus, limit, offset int) ([]model.Raffle, error)
+	CountRaffles(ctx context.Context, status model.RaffleStatus) (int, error)
+	BuyTickets(ctx context.Context, raffleID int64, userID string, quantity int) (*model.RaffleTicketPurchase, error)
+	ListEntries(ctx context.Context, raffleID int64) ([]model.RaffleEntry, error)
+	GetDueRaffles(ctx context.Context, now time.Time) ([]int64, error)
+	DrawRaffle(ctx context.Context, raffleID int64) ([]model.BalanceChange, bool, error)
+	CancelRaffle(ctx context.Context, raffleID int64) ([]model.BalanceChange, error)
+}
+
+func NewRaffleRepo(db *sql.DB) *RaffleRepo {
+	return &RaffleRepo{db: db}
+}
+
+func (r *RaffleRepo) CreateRaffle(ctx context.Context, raffle *model.Raffle, serverSeed string) error {
+	raffle.Status = model.RaffleOpen
+	raffle.CreatedAt = time.Now()
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO raffles (title, description, prize, prize_points, ticket_price, max_tickets_per_user,
+                              winners, draw_at, status, server_seed, server_seed_hash, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`,
+		raffle.Title, raffle.Description, raffle.Prize, raffle.PrizePoints, raffle.TicketPrice,
+		raffle.MaxTicketsPerUser, raffle.Winners, raffle.DrawAt, raffle.Status, serverSeed,
+		raffle.ServerSeedHash, raffle.CreatedAt).Scan(&raffle.ID)
+	if err != nil {
+		return fmt.Errorf("create raffle: %w", err)
+	}
+	return nil
+}
+
+// Серверный сид отдаётся только после розыгрыша или отмены
+const raffleSelect = `SELECT r.id, r.title, r.description, r.prize, r.prize_points, r.ticket_price,
+       r.max_tickets_per_user, r.winners, r.draw_at, r.status, COALESCE(t.sold, 0), COALESCE(t.participants, 0),
+       r.server_seed_hash, CASE WHEN r.status <> 'open' THEN r.server_seed ELSE '' END,
+       COALESCE(r.client_seed, ''), r.created_at, r.drawn_at, r.cancelled_at
+FROM raffles r
+LEFT JOIN LATERAL (
+    SELECT SUM(quantity) AS sold, COUNT(DISTINCT user_id) AS participants
+    FROM raffle_tickets WHERE raffle_id = r.id
+) t ON true`
+
+func scanRaffle(row interface{ Scan(...any) error }) (*model.Raffle, error) {
+	var raffle model.Raffle
+	var drawnAt, cancelledAt sql.NullTime
+	if err := row.Scan(&raffle.ID, &raffle.Title, &raffle.Description, &raffle.Prize, &raffle.PrizePoints,
+		&raffle.TicketPrice, &raffle.MaxTicketsPerUser, &raffle.Winners, &raffle.DrawAt, &raffle.Status,
+		&raffle.TicketsSold, &raffle.Participants, &raffle.ServerSeedHash, &raffle.ServerSeed,
+		&raffle.ClientSeed, &raffle.CreatedAt, &drawnAt, &cancelledAt); err != nil {
+		return nil, err
+	}
+	if drawnAt.Valid {
+		raffle.DrawnAt = &drawnAt.Time
+	}
+	if cancelledAt.Valid {
+		raffle.CancelledAt = &cancelledAt.Time
+	}
+	return &raffle, nil
+}
+
+// GetRaffle возвращает розыгрыш, а для проведённого — и победителей.
+func (r *RaffleRepo) GetRaffle(ctx context.Context, id int64) (*model.Raffle, error) {
+	raffle, err := scanRaffle(r.db.QueryRowContext(ctx, raffleSelect+` WHERE r.id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRaffleNotFound
+		}
+		return nil, fmt.Errorf("get raffle: %w", err)
+	}
+	if raffle.Status != model.RaffleDrawn {
+		return raffle, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT place, user_id, nonce, digest FROM raffle_winners WHERE raffle_id = $1 ORDER BY place`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query raffle winners: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var winner model.RaffleWinner
+		if err := rows.Scan(&winner.Place, &winner.UserID, &winner.Nonce, &winner.Digest); err != nil {
+			return nil, fmt.Errorf("scan raffle winner: %w", err)
+		}
+		raffle.Results = append(raffle.Results, winner)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return raffle, nil
+}
+
+// ListRaffles возвращает розыгрыши по убыванию времени проведения; пустой status — все.
+func (r *RaffleRepo) ListRaffles(ctx context.Context, status model.RaffleStatus, limit, offset int) ([]model.Raffle, error) {
+	rows, err := r.db.QueryContext(ctx,
+		raffleSelect+` WHERE $1 = '' OR r.status = $1
+         ORDER BY r.draw_at DESC, r.id DESC
+         LIMIT $2 OFFSET $3`,
+		status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query raffles: %w", err)
+	}
+	defer rows.Close()
+
+	raffles := []model.Raffle{}
+	for rows.Next() {
+		raffle, err := scanRaffle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan raffle: %w", err)
+		}
+		raffles = append(raffles, *raffle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return raffles, nil
+}
+
+func (r *RaffleRepo) CountRaffles(ctx context.Context, status model.RaffleStatus) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM raffles WHERE $1 = '' OR status = $1`, status).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count raffles: %w", err)
+	}
+	return total, nil
+}
+
+// BuyTickets продаёт quantity билетов. Розыгрыш блокируется на чтение, поэтому покупка
+// не пересекается с розыгрышем или отменой; строка пользователя блокируется, чтобы
+// параллельные покупки не обошли лимит. Очки списываются через spendPoints.
+func (r *RaffleRepo) BuyTickets(ctx context.Context, raffleID int64, userID string, quantity int) (*model.RaffleTicketPurchase, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var price, maxTickets int
+	var status model.RaffleStatus
+	var drawAt time.Time
+	err = tx.QueryRowContext(ctx,
+		`SELECT ticket_price, max_tickets_per_user, status, draw_at FROM raffles WHERE id = $1 FOR SHARE`,
+		raffleID).Scan(&price, &maxTickets, &status, &drawAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRaffleNotFound
+		}
+		return nil, fmt.Errorf("lock raffle: %w", err)
+	}
+
+	now := time.Now()
+	if status != model.RaffleOpen || !drawAt.After(now) {
+		return nil, ErrRaffleClosed
+	}
+
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, fmt.Errorf("lock user: %w", err)
+	}
+
+	var owned int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM raffle_tickets WHERE raffle_id = $1 AND user_id = $2`,
+		raffleID, userID).Scan(&owned)
+	if err != nil {
+		return nil, fmt.Errorf("count user tickets: %w", err)
+	}
+	if maxTickets > 0 && owned+quantity > maxTickets {
+		return nil, ErrRaffleTicketLimit
+	}
+
+	purchase := &model.RaffleTicketPurchase{
+		RaffleID:  raffleID,
+		Quantity:  quantity,
+		Cost:      price * quantity,
+		Tickets:   owned + quantity,
+		CreatedAt: now,
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO raffle_tickets (raffle_id, user_id, quantity, cost, created_at)
+         VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		raffleID, userID, purchase.Quantity, purchase.Cost, now).Scan(&purchase.ID)
+	if err != nil {
+		return nil, fmt.Errorf("create raffle tickets: %w", err)
+	}
+
+	purchase.Change, err = spendPoints(ctx, tx, userID, purchase.Cost, model.LedgerRaffleTicket,
+		strconv.FormatInt(purchase.ID, 10), now)
+	if err != nil {
+		return nil, err
+	}
+	purchase.Balance = purchase.Change.Balance
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return purchase, nil
+}
+
+// Участники в порядке первой покупки; этот же порядок используется при розыгрыше
+const raffleEntriesQuery = `SELECT user_id, SUM(quantity) FROM raffle_tickets
+WHERE raffle_id = $1
+GROUP BY user_id
+ORDER BY MIN(id)`
+
+func scanRaffleEntries(rows *sql.Rows) ([]model.RaffleEntry, error) {
+	defer rows.Close()
+
+	entries := []model.RaffleEntry{}
+	next := 1
+	for rows.Next() {
+		var entry model.RaffleEntry
+		if err := rows.Scan(&entry.UserID, &entry.Tickets); err != nil {
+			return nil, fmt.Errorf("scan raffle entry: %w", err)
+		}
+		entry.FirstTicket = next
+		entry.LastTicket = next + entry.Tickets - 1
+		next = entry.LastTicket + 1
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *RaffleRepo) ListEntries(ctx context.Context, raffleID int64) ([]model.RaffleEntry, error) {
+	rows, err := r.db.QueryContext(ctx, raffleEntriesQuery, raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("query raffle entries: %w", err)
+	}
+	return scanRaffleEntries(rows)
+}
+
+// raffleClientSeed — SHA-256 от списка участников в виде строк "user_id:tickets\n".
+// Он становится известен только после закрытия продаж, поэтому исход зависит от каждой покупки.
+func raffleClientSeed(entries []model.RaffleEntry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.UserID + ":" + strconv.Itoa(entry.Tickets) + "\n")
+	}
+	return fairness.Hash(b.String())
+}
+
+func (r *RaffleRepo) GetDueRaffles(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM raffles WHERE status = $1 AND draw_at <= $2 ORDER BY draw_at`,
+		model.RaffleOpen, now)
+	if err != nil {
+		return nil, fmt.Errorf("query due raffles: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan raffle id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return ids, nil
+}
+
+// DrawRaffle проводит розыгрыш: выбирает победителей по весу их билетов, записывает
+// результаты и начисляет призовые очки. Строка розыгрыша блокируется, поэтому
+// повторный или параллельный запуск ничего не делает и возвращает false.
+func (r *RaffleRepo) DrawRaffle(ctx context.Context, raffleID int64) ([]model.BalanceChange, bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status model.RaffleStatus
+	var winners, prizePoints int
+	var serverSeed string
+	err = tx.QueryRowContext(ctx,
+		`SELECT status, winners, prize_points, server_seed FROM raffles WHERE id = $1 FOR UPDATE`,
+		raffleID).Scan(&status, &winners, &prizePoints, &serverSeed)
+	if err != nil {
+		return nil, false, fmt.Errorf("lock raffle: %w", err)
+	}
+	if status != model.RaffleOpen {
+		return nil, false, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, raffleEntriesQuery, raffleID)
+	if err != nil {
+		return nil, false, fmt.Errorf("query raffle entries: %w", err)
+	}
+	entries, err := scanRaffleEntries(rows)
+	if err != nil {
+		return nil, false, err
+	}
+
+	clientSeed := raffleClientSeed(entries)
+	weights := make([]int, len(entries))
+	for i, entry := range entries {
+		weights[i] = entry.Tickets
+	}
+
+	now := time.Now()
+	var changes []model.BalanceChange
+	for place, result := range fairness.Draw(serverSeed, clientSeed, weights, winners) {
+		userID := entries[result.Index].UserID
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO raffle_winners (raffle_id, place, user_id, nonce, digest) VALUES ($1, $2, $3, $4, $5)`,
+			raffleID, place+1, userID, result.Nonce, result.Digest)
+		if err != nil {
+			return nil, false, fmt.Errorf("record raffle winner: %w", err)
+		}
+
+		if prizePoints > 0 {
+			change, err := applyPoints(ctx, tx, userID, prizePoints, model.LedgerRafflePrize,
+				strconv.FormatInt(raffleID, 10), now)
+			if err != nil {
+				return nil, false, fmt.Errorf("award raffle prize: %w", err)
+			}
+			changes = append(changes, *change)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE raffles SET status = $2, client_seed = $3, drawn_at = $4 WHERE id = $1`,
+		raffleID, model.RaffleDrawn, clientSeed, now)
+	if err != nil {
+		return nil, false, fmt.Errorf("mark raffle drawn: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("commit transaction: %w", err)
+	}
+	return changes, true, nil
+}
+
+// CancelRaffle отменяет непроведённый розыгрыш и возвращает очки за каждую покупку билетов.
+func (r *RaffleRepo) CancelRaffle(ctx context.Context, raffleID int64) ([]model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status model.RaffleStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM raffles WHERE id = $1 FOR UPDATE`, raffleID).Scan(&status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRaffleNotFound
+		}
+		return nil, fmt.Errorf("lock raffle: %w", err)
+	}
+	if status != model.RaffleOpen {
+		return nil, ErrRaffleNotOpen
+	}
+
+	type purchase struct {
+		id     int64
+		userID string
+		cost   int
+	}
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, user_id, cost FROM raffle_tickets WHERE raffle_id = $1 ORDER BY id`, raffleID)
+	if err != nil {
+		return nil, fmt.Errorf("query raffle tickets: %w", err)
+	}
+	var purchases []purchase
+	for rows.Next() {
+		var p purchase
+		if err := rows.Scan(&p.id, &p.userID, &p.cost); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan raffle tickets: %w", err)
+		}
+		purchases = append(purchases, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	now := time.Now()
+	changes := make([]model.BalanceChange, 0, len(purchases))
+	for _, p := range purchases {
+		change, err := restorePoints(ctx, tx, p.userID, p.cost, model.LedgerRaffleRefund, model.LedgerRaffleTicket,
+			strconv.FormatInt(p.id, 10), now)
+		if err != nil {
+			return nil, fmt.Errorf("refund raffle tickets: %w", err)
+		}
+		changes = append(changes, *change)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE raffles SET status = $2, cancelled_at = $3 WHERE id = $1`, raffleID, model.RaffleCancelled, now)
+	if err != nil {
+		return nil, fmt.Errorf("cancel raffle: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return changes, nil
+}
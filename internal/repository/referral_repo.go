@@ -0,0 +1,683 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// ID реферального задания из начальной миграции
+const referralTaskID = "3"
+
+// maxChainScan защищает рекурсивные запросы от зацикленных данных.
+const maxChainScan = 100
+
+var (
+	ErrReferralNotFound   = errors.New("referral not found")
+	ErrReferrerNotFound   = errors.New("referrer not found")
+	ErrSelfReferral       = errors.New("user cannot be their own referrer")
+	ErrReferrerAlreadySet = errors.New("referrer already set")
+	ErrReferralCycle      = errors.New("referral cycle detected")
+)
+
+type ReferralRepo struct {
+	db *sql.DB
+}
+
+type ReferralRepository interface {
+	CreateReferral(ctx context.Context, refereeID, referrerID string, rewardPoints int, flags []string) error
+	GetPendingReferrals(ctx context.Context) ([]model.PendingReferral, error)
+	ReleaseReward(ctx context.Context, refereeID string) (*model.BalanceChange, error)
+	VoidReward(ctx context.Context, refereeID, reason string) error
+	GetDownline(ctx context.Context, userID string, maxDepth int) ([]model.ReferralNode, error)
+	GetCommissionsByLevel(ctx context.Context, userID string) (map[int]int, error)
+	ListInvited(ctx context.Context, referrerID string, limit, offset int) ([]model.InvitedUser, error)
+	CountInvited(ctx context.Context, referrerID string) (int, error)
+	GetRegistrationInfo(ctx context.Context, userID string) (*model.RegistrationInfo, error)
+	CountDuplicateEmails(ctx context.Context, userID string) (int, error)
+	CountReferralsSince(ctx context.Context, referrerID string, since time.Time) (int, error)
+	ListFlagged(ctx context.Context, limit, offset int) ([]model.Referral, error)
+	CountFlagged(ctx context.Context) (int, error)
+	ResolveFlagged(ctx context.Context, refereeID string, approve bool, note string) error
+	RecordClick(ctx context.Context, referrerID, ip, userAgent string) error
+	GetReferralStats(ctx context.Context, referrerID string, from, to time.Time, limit, offset int) ([]model.ReferralStats, error)
+	GetReferralTotals(ctx context.Context, from, to time.Time) (*model.ReferralStats, error)
+}
+
+func NewReferralRepo(db *sql.DB) *ReferralRepo {
+	return &ReferralRepo{db: db}
+}
+
+// CreateReferral привязывает реферера к рефералу. Обе строки пользователей блокируются
+// в одном порядке, поэтому встречные привязки (A→B и B→A) выполняются по очереди
+// и проверка цикла видит результат предыдущей.
+func (r *ReferralRepo) CreateReferral(ctx context.Context, refereeID, referrerID string, rewardPoints int, flags []string) error {
+	if refereeID == referrerID {
+		return ErrSelfReferral
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id, referrer IS NOT NULL FROM users WHERE id IN ($1, $2) ORDER BY id FOR UPDATE`,
+		refereeID, referrerID)
+	if err != nil {
+		return fmt.Errorf("failed to lock users: %w", err)
+	}
+	var refereeExists, referrerExists, alreadySet bool
+	for rows.Next() {
+		var id string
+		var hasReferrer bool
+		if err := rows.Scan(&id, &hasReferrer); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan user: %w", err)
+		}
+		if id == refereeID {
+			refereeExists, alreadySet = true, hasReferrer
+		} else {
+			referrerExists = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows error: %w", err)
+	}
+	switch {
+	case !refereeExists:
+		return fmt.Errorf("user %s: %w", refereeID, sql.ErrNoRows)
+	case !referrerExists:
+		return ErrReferrerNotFound
+	case alreadySet:
+		return ErrReferrerAlreadySet
+	}
+
+	var createsCycle bool
+	err = tx.QueryRowContext(ctx, `
+		WITH RECURSIVE upline AS (
+		    SELECT id, referrer, 1 AS depth FROM users WHERE id = $1
+		    UNION ALL
+		    SELECT u.id, u.referrer, up.depth + 1
+		    FROM users u JOIN upline up ON u.id = up.referrer
+		    WHERE up.depth < $3
+		)
+		SELECT EXISTS(SELECT 1 FROM upline WHERE id = $2)`,
+		referrerID, refereeID, maxChainScan).Scan(&createsCycle)
+	if err != nil {
+		return fmt.Errorf("failed to check referral chain: %w", err)
+	}
+	if createsCycle {
+		return ErrReferralCycle
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx,
+		`UPDATE users SET referrer = $1, updated_at = $2 WHERE id = $3`,
+		referrerID, now, refereeID)
+	if err != nil {
+		return fmt.Errorf("failed to set referrer: %w", err)
+	}
+
+	status := model.ReferralPending
+	if len(flags) > 0 {
+		status = model.ReferralReview
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO referrals (referee_id, referrer_id, date, reward_points, status, flags)
+         VALUES ($1, $2, $3, $4, $5, $6)`,
+		refereeID, referrerID, now, rewardPoints, status, pq.Array(flags))
+	if err != nil {
+		return fmt.Errorf("failed to create referral: %w", err)
+	}
+
+	if err := recordCompletion(ctx, tx, refereeID, referralTaskID, now); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingReferrals возвращает ожидающие рефералы с показателями квалификации.
+// Днём активности считается день с выполненным заданием (кроме реферального) или отметкой.
+func (r *ReferralRepo) GetPendingReferrals(ctx context.Context) ([]model.PendingReferral, error) {
+	query := `
+		SELECT r.referrer_id, r.referee_id, r.date, r.reward_points, r.status,
+		       u.email_verified_at,
+		       (SELECT COUNT(*) FROM user_tasks ut
+		        WHERE ut.user_id = u.id AND ut.task_id <> $2) AS completed_tasks,
+		       (SELECT COUNT(*) FROM (
+		            SELECT tc.completed_at::date FROM task_completions tc
+		            WHERE tc.user_id = u.id AND tc.task_id <> $2
+		            UNION
+		            SELECT c.day FROM checkins c WHERE c.user_id = u.id
+		        ) days) AS active_days
+		FROM referrals r
+		JOIN users u ON u.id = r.referee_id
+		WHERE r.status = $1
+		ORDER BY r.date
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, model.ReferralPending, referralTaskID)
+	if err != nil {
+		return nil, fmt.Errorf("query pending referrals: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []model.PendingReferral
+	for rows.Next() {
+		var p model.PendingReferral
+		var verifiedAt sql.NullTime
+		if err := rows.Scan(
+			&p.ReferrerID,
+			&p.RefereeID,
+			&p.Date,
+			&p.RewardPoints,
+			&p.Status,
+			&verifiedAt,
+			&p.CompletedTasks,
+			&p.ActiveDays,
+		); err != nil {
+			return nil, fmt.Errorf("scan pending referral: %w", err)
+		}
+		if verifiedAt.Valid {
+			p.RefereeVerifiedAt = &verifiedAt.Time
+		}
+		pending = append(pending, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return pending, nil
+}
+
+// ReleaseReward выплачивает награду рефереру; nil без ошибки — награда уже обработана.
+func (r *ReferralRepo) ReleaseReward(ctx context.Context, refereeID string) (*model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var referrerID string
+	var reward int
+	err = tx.QueryRowContext(ctx,
+		`UPDATE referrals SET status = $1, resolved_at = $2, resolution = 'qualified'
+         WHERE referee_id = $3 AND status = $4
+         RETURNING referrer_id, reward_points`,
+		model.ReferralPaid, now, refereeID, model.ReferralPending).Scan(&referrerID, &reward)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// награда уже обработана параллельным запуском
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to mark referral paid: %w", err)
+	}
+
+	change, err := applyPoints(ctx, tx, referrerID, reward, model.LedgerReferralReward, refereeID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add referral bonus: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return change, nil
+}
+
+func (r *ReferralRepo) VoidReward(ctx context.Context, refereeID, reason string) error {
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE referrals SET status = $1, resolved_at = $2, resolution = $3
+         WHERE referee_id = $4 AND status = $5`,
+		model.ReferralVoid, time.Now(), reason, refereeID, model.ReferralPending)
+	if err != nil {
+		return fmt.Errorf("failed to void referral: %w", err)
+	}
+	return nil
+}
+
+func (r *ReferralRepo) GetDownline(ctx context.Context, userID string, maxDepth int) ([]model.ReferralNode, error) {
+	query := `
+		WITH RECURSIVE downline AS (
+		    SELECT id, name, points, referrer, created_at, 1 AS level,
+		           ARRAY[$1::VARCHAR, id] AS path
+		    FROM users WHERE referrer = $1
+		    UNION ALL
+		    SELECT u.id, u.name, u.points, u.referrer, u.created_at, d.level + 1,
+		           d.path || u.id
+		    FROM users u JOIN downline d ON u.referrer = d.id
+		    WHERE d.level < $2 AND NOT u.id = ANY(d.path)
+		)
+		SELECT id, referrer, name, points, created_at, level
+		FROM downline
+		ORDER BY level, created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("query downline: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []model.ReferralNode
+	for rows.Next() {
+		var node model.ReferralNode
+		if err := rows.Scan(
+			&node.UserID,
+			&node.ReferrerID,
+			&node.Name,
+			&node.Points,
+			&node.JoinedAt,
+			&node.Level,
+		); err != nil {
+			return nil, fmt.Errorf("scan downline node: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return nodes, nil
+}
+
+func (r *ReferralRepo) GetCommissionsByLevel(ctx context.Context, userID string) (map[int]int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT level, COALESCE(SUM(points), 0) FROM referral_commissions
+         WHERE beneficiary_id = $1 GROUP BY level`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("query commissions: %w", err)
+	}
+	defer rows.Close()
+
+	commissions := make(map[int]int)
+	for rows.Next() {
+		var level, points int
+		if err := rows.Scan(&level, &points); err != nil {
+			return nil, fmt.Errorf("scan commission: %w", err)
+		}
+		commissions[level] = points
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return commissions, nil
+}
+
+func (r *ReferralRepo) ListInvited(ctx context.Context, referrerID string, limit, offset int) ([]model.InvitedUser, error) {
+	query := `
+		SELECT r.referee_id, u.name, r.date, r.reward_points, r.status,
+		       COALESCE(c.points, 0) AS commission_points
+		FROM referrals r
+		JOIN users u ON u.id = r.referee_id
+		LEFT JOIN (
+		    SELECT source_user_id, SUM(points) AS points
+		    FROM referral_commissions
+		    WHERE beneficiary_id = $1
+		    GROUP BY source_user_id
+		) c ON c.source_user_id = r.referee_id
+		WHERE r.referrer_id = $1
+		ORDER BY r.date DESC, r.referee_id
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, referrerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query invited users: %w", err)
+	}
+	defer rows.Close()
+
+	invited := []model.InvitedUser{}
+	for rows.Next() {
+		var item model.InvitedUser
+		if err := rows.Scan(
+			&item.UserID,
+			&item.Name,
+			&item.Date,
+			&item.RewardPoints,
+			&item.RewardStatus,
+			&item.CommissionPoints,
+		); err != nil {
+			return nil, fmt.Errorf("scan invited user: %w", err)
+		}
+		item.EarnedPoints = item.CommissionPoints
+		if item.RewardStatus == model.ReferralPaid {
+			item.EarnedPoints += item.RewardPoints
+		}
+		invited = append(invited, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return invited, nil
+}
+
+func (r *ReferralRepo) CountInvited(ctx context.Context, referrerID string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM referrals WHERE referrer_id = $1`,
+		referrerID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count invited users: %w", err)
+	}
+	return total, nil
+}
+
+func (r *ReferralRepo) GetRegistrationInfo(ctx context.Context, userID string) (*model.RegistrationInfo, error) {
+	var ip, userAgent, deviceID sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT registration_ip, registration_user_agent, device_id FROM users WHERE id = $1`,
+		userID).Scan(&ip, &userAgent, &deviceID)
+	if err != nil {
+		return nil, fmt.Errorf("get registration info: %w", err)
+	}
+
+	return &model.RegistrationInfo{
+		IP:        ip.String,
+		UserAgent: userAgent.String,
+		DeviceID:  deviceID.String,
+	}, nil
+}
+
+func (r *ReferralRepo) CountDuplicateEmails(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM users other
+		JOIN users u ON u.normalized_email = other.normalized_email
+		WHERE u.id = $1 AND other.id <> u.id`,
+		userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count duplicate emails: %w", err)
+	}
+	return count, nil
+}
+
+func (r *ReferralRepo) CountReferralsSince(ctx context.Context, referrerID string, since time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM referrals r
+		JOIN users u ON u.id = r.referee_id
+		WHERE r.referrer_id = $1 AND u.created_at >= $2`,
+		referrerID, since).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count recent referrals: %w", err)
+	}
+	return count, nil
+}
+
+func (r *ReferralRepo) ListFlagged(ctx context.Context, limit, offset int) ([]model.Referral, error) {
+	query := `
+		SELECT referrer_id, referee_id, date, reward_points, status, flags
+		FROM referrals
+		WHERE status = $1
+		ORDER BY date
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, model.ReferralReview, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query flagged referrals: %w", err)
+	}
+	defer rows.Close()
+
+	flagged := []model.Referral{}
+	for rows.Next() {
+		var ref model.Referral
+		if err := rows.Scan(
+			&ref.ReferrerID,
+			&ref.RefereeID,
+			&ref.Date,
+			&ref.RewardPoints,
+			&ref.Status,
+			pq.Array(&ref.Flags),
+		); err != nil {
+			return nil, fmt.Errorf("scan flagged referral: %w", err)
+		}
+		flagged = append(flagged, ref)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return flagged, nil
+}
+
+func (r *ReferralRepo) CountFlagged(ctx context.Context) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM referrals WHERE status = $1`,
+		model.ReferralReview).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count flagged referrals: %w", err)
+	}
+	return total, nil
+}
+
+// ResolveFlagged возвращает одобренный реферал в очередь на квалификацию
+// либо аннулирует отклонённый.
+func (r *ReferralRepo) ResolveFlagged(ctx context.Context, refereeID string, approve bool, note string) error {
+	var result sql.Result
+	var err error
+	if approve {
+		result, err = r.db.ExecContext(ctx,
+			`UPDATE referrals SET status = $1, resolution = $2
+             WHERE referee_id = $3 AND status = $4`,
+			model.ReferralPending, note, refereeID, model.ReferralReview)
+	} else {
+		result, err = r.db.ExecContext(ctx,
+			`UPDATE referrals SET status = $1, resolved_at = $2, resolution = $3
+             WHERE referee_id = $4 AND status = $5`,
+			model.ReferralVoid, time.Now(), note, refereeID, model.ReferralReview)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to resolve flagged referral: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrReferralNotFound
+	}
+
+	return nil
+}
+
+// RecordClick сохраняет переход по пригласительной ссылке; ссылки на несуществующих
+// пользователей молча игнорируются.
+func (r *ReferralRepo) RecordClick(ctx context.Context, referrerID, ip, userAgent string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO referral_clicks (referrer_id, ip, user_agent, created_at)
+         SELECT $1, $2, $3, $4
+         WHERE EXISTS(SELECT 1 FROM users WHERE id = $1)`,
+		referrerID, ip, userAgent, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record invite click: %w", err)
+	}
+	return nil
+}
+
+// referralStatsCTE собирает показатели по реферерам за период [$1, $2).
+// $3 ограничивает выборку одним реферером, пустая строка — все.
+const referralStatsCTE = `
+	WITH clicks AS (
+	    SELECT referrer_id, COUNT(*) AS clicks FROM referral_clicks
+	    WHERE created_at >= $1 AND created_at < $2 AND ($3 = '' OR referrer_id = $3)
+	    GROUP BY referrer_id
+	), signups AS (
+	    SELECT referrer_id, COUNT(*) AS signups FROM referrals
+	    WHERE date >= $1 AND date < $2 AND ($3 = '' OR referrer_id = $3)
+	    GROUP BY referrer_id
+	), qualified AS (
+	    SELECT referrer_id, COUNT(*) AS qualified FROM referrals
+	    WHERE status = 'paid' AND resolved_at >= $1 AND resolved_at < $2 AND ($3 = '' OR referrer_id = $3)
+	    GROUP BY referrer_id
+	), paid AS (
+	    SELECT user_id AS referrer_id, SUM(amount) AS points FROM points_ledger
+	    WHERE reason IN ('referral_reward', 'referral_commission')
+	      AND created_at >= $1 AND created_at < $2 AND ($3 = '' OR user_id = $3)
+	    GROUP BY user_id
+	), stats AS (
+	    SELECT ids.referrer_id,
+	           COALESCE(clicks.clicks, 0) AS clicks,
+	           COALESCE(signups.signups, 0) AS signups,
+	           COALESCE(qualified.qualified, 0) AS qualified,
+	           COALESCE(paid.points, 0) AS points_paid
+	    FROM (
+	        SELECT referrer_id FROM clicks
+	        UNION SELECT referrer_id FROM signups
+	        UNION SELECT referrer_id FROM qualified
+	        UNION SELECT referrer_id FROM paid
+	    ) ids
+	    LEFT JOIN clicks USING (referrer_id)
+	    LEFT JOIN signups USING (referrer_id)
+	    LEFT JOIN qualified USING (referrer_id)
+	    LEFT JOIN paid USING (referrer_id)
+	)
+`
+
+func (r *ReferralRepo) GetReferralStats(ctx context.Context, referrerID string, from, to time.Time, limit, offset int) ([]model.ReferralStats, error) {
+	query := referralStatsCTE + `
+		SELECT referrer_id, clicks, signups, qualified, points_paid
+		FROM stats
+		ORDER BY signups DESC, points_paid DESC, referrer_id
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, referrerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query referral stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []model.ReferralStats{}
+	for rows.Next() {
+		var st model.ReferralStats
+		if err := rows.Scan(
+			&st.ReferrerID,
+			&st.Clicks,
+			&st.Signups,
+			&st.Qualified,
+			&st.PointsPaid,
+		); err != nil {
+			return nil, fmt.Errorf("scan referral stats: %w", err)
+		}
+		stats = append(stats, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (r *ReferralRepo) GetReferralTotals(ctx context.Context, from, to time.Time) (*model.ReferralStats, error) {
+	query := referralStatsCTE + `
+		SELECT COALESCE(SUM(clicks), 0), COALESCE(SUM(signups), 0),
+		       COALESCE(SUM(qualified), 0), COALESCE(SUM(points_paid), 0)
+		FROM stats
+	`
+
+	var totals model.ReferralStats
+	err := r.db.QueryRowContext(ctx, query, from, to, "").Scan(
+		&totals.Clicks,
+		&totals.Signups,
+		&totals.Qualified,
+		&totals.PointsPaid,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query referral totals: %w", err)
+	}
+	return &totals, nil
+}
+
+// payReferralCommissions начисляет реферерам вверх по цепочке долю очков за задание.
+// rates[i] — ставка для уровня i+1; цепочка обрывается на повторно встреченном пользователе.
+func payReferralCommissions(ctx context.Context, tx *sql.Tx, userID, taskID string, points int, rates []float64) ([]model.BalanceChange, error) {
+	if len(rates) == 0 || points <= 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH RECURSIVE chain AS (
+		    SELECT referrer AS id, 1 AS level, ARRAY[id, referrer]::VARCHAR[] AS path
+		    FROM users WHERE id = $1 AND referrer IS NOT NULL
+		    UNION ALL
+		    SELECT u.referrer, c.level + 1, c.path || u.referrer
+		    FROM chain c JOIN users u ON u.id = c.id
+		    WHERE u.referrer IS NOT NULL AND c.level < $2 AND NOT u.referrer = ANY(c.path)
+		)
+		SELECT id, level FROM chain ORDER BY level`,
+		userID, len(rates))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query referral chain: %w", err)
+	}
+
+	type beneficiary struct {
+		id    string
+		level int
+	}
+	var chain []beneficiary
+	for rows.Next() {
+		var b beneficiary
+		if err := rows.Scan(&b.id, &b.level); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan referral chain: %w", err)
+		}
+		chain = append(chain, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	now := time.Now()
+	var changes []model.BalanceChange
+	for _, b := range chain {
+		commission := int(float64(points) * rates[b.level-1])
+		if commission <= 0 {
+			continue
+		}
+
+		change, err := applyPoints(ctx, tx, b.id, commission, model.LedgerReferralCommission, userID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update referrer points: %w", err)
+		}
+		changes = append(changes, *change)
+
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO referral_commissions (beneficiary_id, source_user_id, task_id, level, points, created_at)
+             VALUES ($1, $2, $3, $4, $5, $6)`,
+			b.id, userID, taskID, b.level, commission, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record referral commission: %w", err)
+		}
+	}
+
+	return changes, nil
+}
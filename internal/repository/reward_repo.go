@@ -0,0 +1,340 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrRewardNotFound    = errors.New("reward not found")
+	ErrRewardUnavailable = errors.New("reward is not available")
+	ErrOutOfStock        = errors.New("reward is out of stock")
+	ErrRedeemLimit       = errors.New("redemption limit reached for this reward")
+	ErrOrderNotFound     = errors.New("order not found")
+	ErrOrderNotPending   = errors.New("order is not pending")
+)
+
+type RewardRepo struct {
+	db *sql.DB
+}
+
+type RewardRepository interface {
+	CreateReward(ctx context.Context, reward *model.Reward) error
+	UpdateReward(ctx context.Context, reward *model.Reward) error
+	GetReward(ctx context.Context, id int64) (*model.Reward, error)
+	ListRewards(ctx context.Context, activeOnly bool) ([]model.Reward, error)
+	Redeem(ctx context.Context, userID string, rewardID int64) (*model.RewardOrder, *model.BalanceChange, error)
+	FulfillOrder(ctx context.Context, orderID int64, note string) error
+	CancelOrder(ctx context.Context, orderID int64, note string) (*model.BalanceChange, error)
+	ListOrders(ctx context.Context, userID string, status model.OrderStatus, limit, offset int) ([]model.RewardOrder, error)
+	CountOrders(ctx context.Context, userID string, status model.OrderStatus) (int, error)
+}
+
+func NewRewardRepo(db *sql.DB) *RewardRepo {
+	return &RewardRepo{db: db}
+}
+
+func (r *RewardRepo) CreateReward(ctx context.Context, reward *model.Reward) error {
+	now := time.Now()
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO rewards (name, description, cost, stock, per_user_limit, active, created_at, updated_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $7) RETURNING id`,
+		reward.Name, reward.Description, reward.Cost, reward.Stock, reward.PerUserLimit, reward.Active, now,
+	).Scan(&reward.ID)
+	if err != nil {
+		return fmt.Errorf("create reward: %w", err)
+	}
+	reward.CreatedAt = now
+	return nil
+}
+
+func (r *RewardRepo) UpdateReward(ctx context.Context, reward *model.Reward) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE rewards SET name = $1, description = $2, cost = $3, stock = $4,
+             per_user_limit = $5, active = $6, updated_at = $7
+         WHERE id = $8`,
+		reward.Name, reward.Description, reward.Cost, reward.Stock, reward.PerUserLimit, reward.Active,
+		time.Now(), reward.ID)
+	if err != nil {
+		return fmt.Errorf("update reward: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrRewardNotFound
+	}
+	return nil
+}
+
+const rewardColumns = `id, name, description, cost, stock, per_user_limit, active, created_at`
+
+func scanReward(row interface{ Scan(...any) error }) (*model.Reward, error) {
+	var reward model.Reward
+	var stock, limit sql.NullInt64
+	if err := row.Scan(&reward.ID, &reward.Name, &reward.Description, &reward.Cost,
+		&stock, &limit, &reward.Active, &reward.CreatedAt); err != nil {
+		return nil, err
+	}
+	if stock.Valid {
+		v := int(stock.Int64)
+		reward.Stock = &v
+	}
+	if limit.Valid {
+		v := int(limit.Int64)
+		reward.PerUserLimit = &v
+	}
+	return &reward, nil
+}
+
+func (r *RewardRepo) GetReward(ctx context.Context, id int64) (*model.Reward, error) {
+	reward, err := scanReward(r.db.QueryRowContext(ctx,
+		`SELECT `+rewardColumns+` FROM rewards WHERE id = $1`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRewardNotFound
+		}
+		return nil, fmt.Errorf("get reward: %w", err)
+	}
+	return reward, nil
+}
+
+func (r *RewardRepo) ListRewards(ctx context.Context, activeOnly bool) ([]model.Reward, error) {
+	query := `SELECT ` + rewardColumns + ` FROM rewards`
+	if activeOnly {
+		query += ` WHERE active`
+	}
+	query += ` ORDER BY cost, id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query rewards: %w", err)
+	}
+	defer rows.Close()
+
+	rewards := []model.Reward{}
+	for rows.Next() {
+		reward, err := scanReward(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan reward: %w", err)
+		}
+		rewards = append(rewards, *reward)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return rewards, nil
+}
+
+// Redeem оформляет заказ: списывает очки и уменьшает остаток в одной транзакции.
+// Строка награды блокируется, поэтому остаток и лимит на пользователя проверяются
+// без гонок, а списание через spendPoints не даёт балансу уйти в минус.
+func (r *RewardRepo) Redeem(ctx context.Context, userID string, rewardID int64) (*model.RewardOrder, *model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reward, err := scanReward(tx.QueryRowContext(ctx,
+		`SELECT `+rewardColumns+` FROM rewards WHERE id = $1 FOR UPDATE`, rewardID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, ErrRewardNotFound
+		}
+		return nil, nil, fmt.Errorf("lock reward: %w", err)
+	}
+	if !reward.Active {
+		return nil, nil, ErrRewardUnavailable
+	}
+	if reward.Stock != nil && *reward.Stock == 0 {
+		return nil, nil, ErrOutOfStock
+	}
+
+	if reward.PerUserLimit != nil {
+		var redeemed int
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM reward_orders
+             WHERE user_id = $1 AND reward_id = $2 AND status <> $3`,
+			userID, rewardID, model.OrderCancelled).Scan(&redeemed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("count user orders: %w", err)
+		}
+		if redeemed >= *reward.PerUserLimit {
+			return nil, nil, ErrRedeemLimit
+		}
+	}
+
+	now := time.Now()
+	order := &model.RewardOrder{
+		RewardID:   reward.ID,
+		RewardName: reward.Name,
+		UserID:     userID,
+		Cost:       reward.Cost,
+		Status:     model.OrderPending,
+		CreatedAt:  now,
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO reward_orders (reward_id, user_id, cost, status, created_at)
+         VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		order.RewardID, order.UserID, order.Cost, order.Status, order.CreatedAt).Scan(&order.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create order: %w", err)
+	}
+
+	change, err := spendPoints(ctx, tx, userID, reward.Cost, model.LedgerRewardRedemption,
+		strconv.FormatInt(order.ID, 10), now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reward.Stock != nil {
+		_, err = tx.ExecContext(ctx, `UPDATE rewards SET stock = stock - 1 WHERE id = $1`, rewardID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decrement stock: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return order, change, nil
+}
+
+func (r *RewardRepo) FulfillOrder(ctx context.Context, orderID int64, note string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE reward_orders SET status = $1, fulfilled_at = $2, note = $3
+         WHERE id = $4 AND status = $5`,
+		model.OrderFulfilled, time.Now(), note, orderID, model.OrderPending)
+	if err != nil {
+		return fmt.Errorf("fulfill order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return r.orderStateError(ctx, orderID)
+	}
+	return nil
+}
+
+// CancelOrder отменяет ожидающий заказ, возвращает очки и единицу на склад.
+func (r *RewardRepo) CancelOrder(ctx context.Context, orderID int64, note string) (*model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var userID string
+	var rewardID int64
+	var cost int
+	err = tx.QueryRowContext(ctx,
+		`UPDATE reward_orders SET status = $1, cancelled_at = $2, note = $3
+         WHERE id = $4 AND status = $5
+         RETURNING user_id, reward_id, cost`,
+		model.OrderCancelled, now, note, orderID, model.OrderPending).Scan(&userID, &rewardID, &cost)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.orderStateError(ctx, orderID)
+		}
+		return nil, fmt.Errorf("cancel order: %w", err)
+	}
+
+	change, err := restorePoints(ctx, tx, userID, cost, model.LedgerRewardRefund, model.LedgerRewardRedemption,
+		strconv.FormatInt(orderID, 10), now)
+	if err != nil {
+		return nil, fmt.Errorf("refund order: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE rewards SET stock = stock + 1 WHERE id = $1 AND stock IS NOT NULL`, rewardID)
+	if err != nil {
+		return nil, fmt.Errorf("restock reward: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return change, nil
+}
+
+func (r *RewardRepo) orderStateError(ctx context.Context, orderID int64) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM reward_orders WHERE id = $1)`, orderID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check order existence: %w", err)
+	}
+	if !exists {
+		return ErrOrderNotFound
+	}
+	return ErrOrderNotPending
+}
+
+// ListOrders возвращает заказы пользователя (или всех, если userID пуст),
+// при непустом status — только в этом статусе.
+func (r *RewardRepo) ListOrders(ctx context.Context, userID string, status model.OrderStatus, limit, offset int) ([]model.RewardOrder, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT o.id, o.reward_id, rw.name, o.user_id, o.cost, o.status, o.note,
+                o.created_at, o.fulfilled_at, o.cancelled_at
+         FROM reward_orders o
+         JOIN rewards rw ON rw.id = o.reward_id
+         WHERE ($1 = '' OR o.user_id = $1) AND ($2 = '' OR o.status = $2)
+         ORDER BY o.created_at DESC, o.id DESC
+         LIMIT $3 OFFSET $4`,
+		userID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := []model.RewardOrder{}
+	for rows.Next() {
+		var order model.RewardOrder
+		var fulfilledAt, cancelledAt sql.NullTime
+		if err := rows.Scan(&order.ID, &order.RewardID, &order.RewardName, &order.UserID, &order.Cost,
+			&order.Status, &order.Note, &order.CreatedAt, &fulfilledAt, &cancelledAt); err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		if fulfilledAt.Valid {
+			order.FulfilledAt = &fulfilledAt.Time
+		}
+		if cancelledAt.Valid {
+			order.CancelledAt = &cancelledAt.Time
+		}
+		orders = append(orders, order)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return orders, nil
+}
+
+func (r *RewardRepo) CountOrders(ctx context.Context, userID string, status model.OrderStatus) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM reward_orders
+         WHERE ($1 = '' OR user_id = $1) AND ($2 = '' OR status = $2)`,
+		userID, status).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count orders: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var ErrSeasonNotFound = errors.New("season not found")
+
+type SeasonRepo struct {
+	db *sql.DB
+}
+
+type SeasonRepository interface {
+	CreateSeason(ctx context.Context, season *model.Season) error
+	GetSeason(ctx context.Context, id int64) (*model.Season, error)
+	ListSeasons(ctx context.Context) ([]model.Season, error)
+	GetSnapshot(ctx context.Context, seasonID int64, limit, offset int) ([]model.SeasonEntry, error)
+	CountSnapshot(ctx context.Context, seasonID int64) (int, error)
+	GetDueSeasons(ctx context.Context, now time.Time) ([]int64, error)
+	SnapshotSeason(ctx context.Context, seasonID int64) (bool, error)
+}
+
+func NewSeasonRepo(db *sql.DB) *SeasonRepo {
+	return &SeasonRepo{db: db}
+}
+
+func (r *SeasonRepo) CreateSeason(ctx context.Context, season *model.Season) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO seasons (name, starts_at, ends_at, created_at)
+         VALUES ($1, $2, $3, $4) RETURNING id`,
+		season.Name, season.StartsAt, season.EndsAt, time.Now()).Scan(&season.ID)
+	if err != nil {
+		return fmt.Errorf("create season: %w", err)
+	}
+
+	for _, reward := range season.Rewards {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO season_rewards (season_id, position_from, position_to, points)
+             VALUES ($1, $2, $3, $4)`,
+			season.ID, reward.PositionFrom, reward.PositionTo, reward.Points)
+		if err != nil {
+			return fmt.Errorf("create season reward: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SeasonRepo) GetSeason(ctx context.Context, id int64) (*model.Season, error) {
+	var season model.Season
+	var snapshottedAt sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, starts_at, ends_at, snapshotted_at FROM seasons WHERE id = $1`,
+		id).Scan(&season.ID, &season.Name, &season.StartsAt, &season.EndsAt, &snapshottedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSeasonNotFound
+		}
+		return nil, fmt.Errorf("get season: %w", err)
+	}
+	if snapshottedAt.Valid {
+		season.SnapshottedAt = &snapshottedAt.Time
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT position_from, position_to, points FROM season_rewards
+         WHERE season_id = $1 ORDER BY position_from`,
+		id)
+	if err != nil {
+		return nil, fmt.Errorf("query season rewards: %w", err)
+	}
+	defer rows.Close()
+
+	season.Rewards = []model.SeasonReward{}
+	for rows.Next() {
+		var reward model.SeasonReward
+		if err := rows.Scan(&reward.PositionFrom, &reward.PositionTo, &reward.Points); err != nil {
+			return nil, fmt.Errorf("scan season reward: %w", err)
+		}
+		season.Rewards = append(season.Rewards, reward)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return &season, nil
+}
+
+func (r *SeasonRepo) ListSeasons(ctx context.Context) ([]model.Season, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, starts_at, ends_at, snapshotted_at FROM seasons ORDER BY starts_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query seasons: %w", err)
+	}
+	defer rows.Close()
+
+	seasons := []model.Season{}
+	for rows.Next() {
+		var season model.Season
+		var snapshottedAt sql.NullTime
+		if err := rows.Scan(&season.ID, &season.Name, &season.StartsAt, &season.EndsAt, &snapshottedAt); err != nil {
+			return nil, fmt.Errorf("scan season: %w", err)
+		}
+		if snapshottedAt.Valid {
+			season.SnapshottedAt = &snapshottedAt.Time
+		}
+		seasons = append(seasons, season)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return seasons, nil
+}
+
+func (r *SeasonRepo) GetSnapshot(ctx context.Context, seasonID int64, limit, offset int) ([]model.SeasonEntry, error) {
+	query := `
+		SELECT s.user_id, u.name, s.points, s.position, s.reward_points
+		FROM season_snapshots s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.season_id = $1
+		ORDER BY s.position
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, seasonID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query season snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.SeasonEntry{}
+	for rows.Next() {
+		var entry model.SeasonEntry
+		if err := rows.Scan(
+			&entry.UserID,
+			&entry.Name,
+			&entry.Points,
+			&entry.Position,
+			&entry.RewardPoints,
+		); err != nil {
+			return nil, fmt.Errorf("scan season snapshot: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *SeasonRepo) CountSnapshot(ctx context.Context, seasonID int64) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM season_snapshots WHERE season_id = $1`,
+		seasonID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count season snapshot: %w", err)
+	}
+	return total, nil
+}
+
+func (r *SeasonRepo) GetDueSeasons(ctx context.Context, now time.Time) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id FROM seasons WHERE snapshotted_at IS NULL AND ends_at <= $1 ORDER BY ends_at`,
+		now)
+	if err != nil {
+		return nil, fmt.Errorf("query due seasons: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan season id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
+
+// SnapshotSeason фиксирует итоговый рейтинг сезона и начисляет награды.
+// Строка сезона блокируется на время транзакции, поэтому повторный или
+// параллельный запуск ничего не делает и возвращает false.
+func (r *SeasonRepo) SnapshotSeason(ctx context.Context, seasonID int64) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var startsAt, endsAt time.Time
+	var snapshottedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT starts_at, ends_at, snapshotted_at FROM seasons WHERE id = $1 FOR UPDATE`,
+		seasonID).Scan(&startsAt, &endsAt, &snapshottedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrSeasonNotFound
+		}
+		return false, fmt.Errorf("lock season: %w", err)
+	}
+	if snapshottedAt.Valid {
+		return false, nil
+	}
+
+	scores, args := leaderboardScores(model.LeaderboardQuery{Since: &startsAt, Until: &endsAt})
+	seasonArg := len(args) + 1
+	query := fmt.Sprintf(`
+		WITH %s, %s
+		INSERT INTO season_snapshots (season_id, user_id, position, points, reward_points)
+		SELECT $%d, r.id, r.position, r.score,
+		       COALESCE((SELECT sr.points FROM season_rewards sr
+		                 WHERE sr.season_id = $%d AND r.position BETWEEN sr.position_from AND sr.position_to
+		                 ORDER BY sr.position_from LIMIT 1), 0)
+		FROM ranked r
+		ON CONFLICT (season_id, user_id) DO NOTHING
+	`, scores, rankedScores, seasonArg, seasonArg)
+	args = append(args, seasonID)
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return false, fmt.Errorf("write season snapshot: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT user_id, reward_points FROM season_snapshots
+         WHERE season_id = $1 AND reward_points > 0`,
+		seasonID)
+	if err != nil {
+		return false, fmt.Errorf("query season winners: %w", err)
+	}
+
+	type payout struct {
+		userID string
+		points int
+	}
+	var payouts []payout
+	for rows.Next() {
+		var p payout
+		if err := rows.Scan(&p.userID, &p.points); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("scan season winner: %w", err)
+		}
+		payouts = append(payouts, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("rows error: %w", err)
+	}
+
+	now := time.Now()
+	reference := strconv.FormatInt(seasonID, 10)
+	for _, p := range payouts {
+		if _, err := applyPoints(ctx, tx, p.userID, p.points, model.LedgerSeasonReward, reference, now); err != nil {
+			return false, fmt.Errorf("pay season reward: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE seasons SET snapshotted_at = $1 WHERE id = $2`, now, seasonID)
+	if err != nil {
+		return false, fmt.Errorf("mark season snapshotted: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return true, nil
+}
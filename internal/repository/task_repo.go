@@ -4,11 +4,15 @@ import (
 	"Test/internal/model"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 )
 
+var ErrTaskNotFound = errors.New("task not found")
+
 type TaskRepo struct {
 	db *sql.DB
 }
@@ -16,30 +20,43 @@ type TaskRepo struct {
 type TaskRepository interface {
 	GetTaskByID(ctx context.Context, id string) (*model.Task, error)
 	GetCompletedTasks(ctx context.Context, userID string) ([]model.Task, error)
-	CompleteTask(ctx context.Context, userID, taskID string) error
-	GetReferrals(ctx context.Context, userID string) ([]model.User, error)
+	CompleteTask(ctx context.Context, userID, taskName string, commissionRates []float64, pointsMultiplier float64) (*model.TaskCompletion, error)
 }
 
 func NewTaskRepo(db *sql.DB) *TaskRepo {
 	return &TaskRepo{db: db}
 }
 
-func (r *TaskRepo) GetTaskByID(ctx context.Context, id string) (*model.Task, error) {
-	query := `SELECT id, name, description, points FROM tasks WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, id)
+// taskRewards — колонка с наградами задания t по валютам в виде JSON-объекта.
+const taskRewards = `COALESCE((SELECT json_object_agg(tr.currency, tr.amount) FROM task_rewards tr
+                WHERE tr.task_id = t.id), '{}')`
 
+func scanTask(row interface{ Scan(...any) error }) (*model.Task, error) {
 	var task model.Task
-	if err := row.Scan(&task.ID, &task.Name, &task.Description, &task.Points); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("task not found")
+	var rewards []byte
+	if err := row.Scan(&task.ID, &task.Name, &task.Description, &task.Repeatable, &rewards); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rewards, &task.Rewards); err != nil {
+		return nil, fmt.Errorf("decode task rewards: %w", err)
+	}
+	return &task, nil
+}
+
+func (r *TaskRepo) GetTaskByID(ctx context.Context, id string) (*model.Task, error) {
+	query := `SELECT t.id, t.name, t.description, t.repeatable, ` + taskRewards + ` FROM tasks t WHERE t.id = $1`
+	task, err := scanTask(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
 		}
 		return nil, fmt.Errorf("get task by id: %w", err)
 	}
-	return &task, nil
+	return task, nil
 }
 
 func (r *TaskRepo) GetCompletedTasks(ctx context.Context, userID string) ([]model.Task, error) {
-	query := `SELECT t.id, t.name, t.description, t.points 
+	query := `SELECT t.id, t.name, t.description, t.repeatable, ` + taskRewards + `
 		FROM tasks t
 		JOIN user_tasks ut ON t.id = ut.task_id
 		WHERE ut.user_id = $1 AND ut.completed_at IS NOT NULL`
@@ -52,11 +69,11 @@ func (r *TaskRepo) GetCompletedTasks(ctx context.Context, userID string) ([]mode
 
 	var tasks []model.Task
 	for rows.Next() {
-		var task model.Task
-		if err := rows.Scan(&task.ID, &task.Name, &task.Description, &task.Points); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan task: %w", err)
 		}
-		tasks = append(tasks, task)
+		tasks = append(tasks, *task)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -66,112 +83,98 @@ func (r *TaskRepo) GetCompletedTasks(ctx context.Context, userID string) ([]mode
 	return tasks, nil
 }
 
-func (r *TaskRepo) CompleteTask(ctx context.Context, userID, taskName string) error {
+// recordCompletion отмечает задание выполненным в user_tasks и пишет выполнение в журнал.
+func recordCompletion(ctx context.Context, db querier, userID, taskID string, at time.Time) error {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO user_tasks (user_id, task_id, completed_at)
+         VALUES ($1, $2, $3)
+         ON CONFLICT (user_id, task_id) DO UPDATE SET completed_at = $3`,
+		userID, taskID, at)
+	if err != nil {
+		return fmt.Errorf("failed to complete task: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO task_completions (user_id, task_id, completed_at) VALUES ($1, $2, $3)`,
+		userID, taskID, at)
+	if err != nil {
+		return fmt.Errorf("failed to record task completion: %w", err)
+	}
+	return nil
+}
+
+// CompleteTask отмечает задание выполненным и начисляет награду; очки умножаются
+// на pointsMultiplier (бонус тира), опыт и комиссии рефереров — нет. Обычное задание
+// выполняется один раз, повторяемое — сколько угодно; каждое выполнение пишется в task_completions.
+func (r *TaskRepo) CompleteTask(ctx context.Context, userID, taskName string, commissionRates []float64, pointsMultiplier float64) (*model.TaskCompletion, error) {
 	var taskID string
 	err := r.db.QueryRowContext(ctx,
 		`SELECT id FROM tasks WHERE name = $1`, taskName).Scan(&taskID)
 	if err != nil {
-		return fmt.Errorf("task not found: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("find task: %w", err)
 	}
 
 	task, err := r.GetTaskByID(ctx, taskID)
 	if err != nil {
-		return fmt.Errorf("task check failed: %w", err)
+		return nil, fmt.Errorf("task check failed: %w", err)
 	}
 
-	var exists bool
-	checkQuery := `SELECT EXISTS(SELECT 1 FROM user_tasks WHERE user_id = $1 AND task_id = $2 AND completed_at IS NOT NULL)`
-	err = r.db.QueryRowContext(ctx, checkQuery, userID, taskID).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("failed to check task completion: %w", err)
-	}
+	if !task.Repeatable {
+		var exists bool
+		checkQuery := `SELECT EXISTS(SELECT 1 FROM user_tasks WHERE user_id = $1 AND task_id = $2 AND completed_at IS NOT NULL)`
+		err = r.db.QueryRowContext(ctx, checkQuery, userID, taskID).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check task completion: %w", err)
+		}
 
-	if exists {
-		return errors.New("task already completed")
+		if exists {
+			return nil, errors.New("task already completed")
+		}
 	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("begin transaction: %w", err)
+		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx,
-		`INSERT INTO user_tasks (user_id, task_id, completed_at) 
-         VALUES ($1, $2, $3)
-         ON CONFLICT (user_id, task_id) DO UPDATE SET completed_at = $3`,
-		userID, taskID, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to complete task: %w", err)
-	}
-
-	_, err = tx.ExecContext(ctx,
-		`UPDATE users SET points = points + $1, updated_at = $2 WHERE id = $3`,
-		task.Points, time.Now(), userID)
-	if err != nil {
-		return fmt.Errorf("failed to update user points: %w", err)
+	now := time.Now()
+	if err := recordCompletion(ctx, tx, userID, taskID, now); err != nil {
+		return nil, err
 	}
 
-	if taskID == "3" {
-		var referrerID sql.NullString
-		err = tx.QueryRowContext(ctx,
-			`SELECT referrer FROM users WHERE id = $1`,
-			userID).Scan(&referrerID)
-		if err != nil {
-			return fmt.Errorf("failed to get referrer: %w", err)
+	var changes []model.BalanceChange
+	for _, currency := range []model.Currency{model.CurrencyPoints, model.CurrencyXP} {
+		amount := task.Rewards[currency]
+		if currency == model.CurrencyPoints {
+			amount = int(math.Round(float64(amount) * pointsMultiplier))
 		}
-
-		if referrerID.Valid && referrerID.String != "" {
-			_, err = tx.ExecContext(ctx,
-				`UPDATE users SET points = points + $1, updated_at = $2 WHERE id = $3`,
-				task.Points/2, time.Now(), referrerID.String)
-			if err != nil {
-				return fmt.Errorf("failed to update referrer points: %w", err)
-			}
+		if amount <= 0 {
+			continue
 		}
+		change, err := award(ctx, tx, userID, currency, amount, model.LedgerTask, taskID, now)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("commit transaction: %w", err)
-	}
-
-	return nil
-}
-
-func (r *TaskRepo) GetReferrals(ctx context.Context, userID string) ([]model.User, error) {
-	query := `
-		SELECT u.id, u.name, u.email, u.points, u.created_at, u.updated_at
-		FROM users u
-		JOIN referrals r ON u.id = r.referee_id
-		WHERE r.referrer_id = $1
-		ORDER BY r.date DESC
-	`
-
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	// Комиссии рефереров считаются только от тратимых очков
+	commissions, err := payReferralCommissions(ctx, tx, userID, taskID, task.Rewards[model.CurrencyPoints], commissionRates)
 	if err != nil {
-		return nil, fmt.Errorf("query referrals: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var referrals []model.User
-	for rows.Next() {
-		var user model.User
-		if err := rows.Scan(
-			&user.ID,
-			&user.Name,
-			&user.Email,
-			&user.Points,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan referral: %w", err)
-		}
-		referrals = append(referrals, user)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows error: %w", err)
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
 	}
 
-	return referrals, nil
+	return &model.TaskCompletion{
+		UserID:  userID,
+		Task:    *task,
+		Changes: append(changes, commissions...),
+	}, nil
 }
@@ -0,0 +1,605 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// challengeGrace — сколько после окончания челленджа ещё проверять его выполнение:
+// событие о последнем задании может прийти с задержкой.
+const challengeGrace = time.Hour
+
+var (
+	ErrTeamNotFound   = errors.New("team not found")
+	ErrTeamNameTaken  = errors.New("team name is already taken")
+	ErrAlreadyInTeam  = errors.New("user is already in a team")
+	ErrInviteNotFound = errors.New("invite code not found")
+	ErrTeamFull       = errors.New("team is full")
+	ErrNotTeamMember  = errors.New("user is not a member of this team")
+	ErrNotTeamCaptain = errors.New("only the team captain can do this")
+)
+
+// teamEarningReasons — начисления, которые идут в зачёт команды.
+var teamEarningReasons = reasonsArray(earningReasons)
+
+type TeamRepo struct {
+	db *sql.DB
+}
+
+type TeamRepository interface {
+	CreateTeam(ctx context.Context, team *model.Team, userID string) error
+	GetTeam(ctx context.Context, teamID int64) (*model.Team, error)
+	ListMembers(ctx context.Context, teamID int64) ([]model.TeamMemberStatus, error)
+	UserTeamID(ctx context.Context, userID string) (int64, error)
+	JoinTeam(ctx context.Context, inviteCode, userID string, maxMembers int) (int64, error)
+	LeaveTeam(ctx context.Context, teamID int64, userID string) error
+	RemoveMember(ctx context.Context, teamID int64, captainID, memberID string) error
+	SetCaptain(ctx context.Context, teamID int64, captainID, newCaptainID string) error
+	SetInviteCode(ctx context.Context, teamID int64, captainID, code string) error
+	GetLeaderboard(ctx context.Context, limit, offset int) ([]model.TeamLeaderboardEntry, error)
+	CountTeams(ctx context.Context) (int, error)
+	CreateChallenge(ctx context.Context, challenge *model.TeamChallenge) error
+	ListChallenges(ctx context.Context, teamID int64) ([]model.TeamChallenge, error)
+	CompleteReachedChallenges(ctx context.Context, teamID int64) ([]model.TeamChallenge, []model.BalanceChange, error)
+}
+
+func NewTeamRepo(db *sql.DB) *TeamRepo {
+	return &TeamRepo{db: db}
+}
+
+// Очки команды t: начисления участников за время их членства ($1 — teamEarningReasons)
+const teamPointsExpr = `COALESCE((
+    SELECT SUM(l.amount) FROM team_memberships m
+    JOIN points_ledger l ON l.user_id = m.user_id AND l.created_at >= m.joined_at
+                        AND (m.left_at IS NULL OR l.created_at < m.left_at)
+    WHERE m.team_id = t.id AND l.currency = 'points' AND l.amount > 0 AND l.reason = ANY($1)
+), 0)`
+
+const teamMembersExpr = `(SELECT COUNT(*) FROM team_memberships WHERE team_id = t.id AND left_at IS NULL)`
+
+// lockActiveMembership блокирует строку пользователя и возвращает ErrAlreadyInTeam,
+// если он уже состоит в команде.
+func lockActiveMembership(ctx context.Context, tx *sql.Tx, userID string) error {
+	var inTeam bool
+	err := tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM team_memberships WHERE user_id = u.id AND left_at IS NULL)
+         FROM users u WHERE u.id = $1 FOR UPDATE`, userID).Scan(&inTeam)
+	if err != nil {
+		return fmt.Errorf("lock user: %w", err)
+	}
+	if inTeam {
+		return ErrAlreadyInTeam
+	}
+	return nil
+}
+
+// CreateTeam создаёт команду, создатель становится капитаном.
+func (r *TeamRepo) CreateTeam(ctx context.Context, team *model.Team, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveMembership(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO teams (name, invite_code, captain_id, created_at) VALUES ($1, $2, $3, $4) RETURNING id`,
+		team.Name, team.InviteCode, userID, now).Scan(&team.ID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_teams_name" {
+			return ErrTeamNameTaken
+		}
+		return fmt.Errorf("create team: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO team_memberships (team_id, user_id, joined_at) VALUES ($1, $2, $3)`, team.ID, userID, now)
+	if err != nil {
+		return fmt.Errorf("add team captain: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	team.CaptainID = userID
+	team.MemberCount = 1
+	team.CreatedAt = now
+	return nil
+}
+
+func (r *TeamRepo) GetTeam(ctx context.Context, teamID int64) (*model.Team, error) {
+	var team model.Team
+	var captainID sql.NullString
+	err := r.db.QueryRowContext(ctx,
+		`SELECT t.id, t.name, t.captain_id, t.invite_code, `+teamMembersExpr+`, `+teamPointsExpr+`, t.created_at
+         FROM teams t WHERE t.id = $2 AND t.disbanded_at IS NULL`,
+		teamEarningReasons, teamID).Scan(&team.ID, &team.Name, &captainID, &team.InviteCode,
+		&team.MemberCount, &team.Points, &team.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("get team: %w", err)
+	}
+	team.CaptainID = captainID.String
+	return &team, nil
+}
+
+// ListMembers возвращает текущих участников в порядке вступления с очками за текущее членство.
+func (r *TeamRepo) ListMembers(ctx context.Context, teamID int64) ([]model.TeamMemberStatus, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT m.user_id, u.name, m.joined_at, t.captain_id IS NOT DISTINCT FROM m.user_id,
+                COALESCE(SUM(l.amount), 0)
+         FROM team_memberships m
+         JOIN teams t ON t.id = m.team_id
+         JOIN users u ON u.id = m.user_id
+         LEFT JOIN points_ledger l ON l.user_id = m.user_id AND l.created_at >= m.joined_at
+                                  AND l.currency = 'points' AND l.amount > 0 AND l.reason = ANY($2)
+         WHERE m.team_id = $1 AND m.left_at IS NULL
+         GROUP BY m.id, u.name, t.captain_id
+         ORDER BY m.joined_at, m.id`,
+		teamID, teamEarningReasons)
+	if err != nil {
+		return nil, fmt.Errorf("query team members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []model.TeamMemberStatus{}
+	for rows.Next() {
+		var member model.TeamMemberStatus
+		var captain bool
+		if err := rows.Scan(&member.UserID, &member.Name, &member.JoinedAt, &captain, &member.Points); err != nil {
+			return nil, fmt.Errorf("scan team member: %w", err)
+		}
+		member.Role = model.TeamMember
+		if captain {
+			member.Role = model.TeamCaptain
+		}
+		members = append(members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return members, nil
+}
+
+// UserTeamID возвращает команду пользователя или 0, если он ни в одной не состоит.
+func (r *TeamRepo) UserTeamID(ctx context.Context, userID string) (int64, error) {
+	var teamID int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT team_id FROM team_memberships WHERE user_id = $1 AND left_at IS NULL`, userID).Scan(&teamID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("get user team: %w", err)
+	}
+	return teamID, nil
+}
+
+func (r *TeamRepo) JoinTeam(ctx context.Context, inviteCode, userID string, maxMembers int) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockActiveMembership(ctx, tx, userID); err != nil {
+		return 0, err
+	}
+
+	var teamID int64
+	err = tx.QueryRowContext(ctx,
+		`SELECT id FROM teams WHERE invite_code = $1 AND disbanded_at IS NULL FOR UPDATE`, inviteCode).Scan(&teamID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInviteNotFound
+		}
+		return 0, fmt.Errorf("find team by invite code: %w", err)
+	}
+
+	if maxMembers > 0 {
+		var members int
+		err := tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM team_memberships WHERE team_id = $1 AND left_at IS NULL`, teamID).Scan(&members)
+		if err != nil {
+			return 0, fmt.Errorf("count team members: %w", err)
+		}
+		if members >= maxMembers {
+			return 0, ErrTeamFull
+		}
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO team_memberships (team_id, user_id, joined_at) VALUES ($1, $2, $3)`, teamID, userID, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("join team: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return teamID, nil
+}
+
+// lockTeam блокирует строку команды и возвращает её капитана.
+func lockTeam(ctx context.Context, tx *sql.Tx, teamID int64) (string, error) {
+	var captainID sql.NullString
+	err := tx.QueryRowContext(ctx,
+		`SELECT captain_id FROM teams WHERE id = $1 AND disbanded_at IS NULL FOR UPDATE`, teamID).Scan(&captainID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrTeamNotFound
+		}
+		return "", fmt.Errorf("lock team: %w", err)
+	}
+	return captainID.String, nil
+}
+
+// endMembership выводит пользователя из команды. Если ушёл капитан, капитаном становится
+// самый давний участник; команда без участников распускается.
+func endMembership(ctx context.Context, tx *sql.Tx, teamID int64, userID string) error {
+	now := time.Now()
+	result, err := tx.ExecContext(ctx,
+		`UPDATE team_memberships SET left_at = $3 WHERE team_id = $1 AND user_id = $2 AND left_at IS NULL`,
+		teamID, userID, now)
+	if err != nil {
+		return fmt.Errorf("leave team: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotTeamMember
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE teams SET captain_id = (
+             SELECT user_id FROM team_memberships WHERE team_id = $1 AND left_at IS NULL
+             ORDER BY joined_at, id LIMIT 1
+         )
+         WHERE id = $1 AND captain_id = $2`,
+		teamID, userID)
+	if err != nil {
+		return fmt.Errorf("reassign team captain: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE teams SET disbanded_at = $2
+         WHERE id = $1 AND NOT EXISTS(SELECT 1 FROM team_memberships WHERE team_id = $1 AND left_at IS NULL)`,
+		teamID, now)
+	if err != nil {
+		return fmt.Errorf("disband team: %w", err)
+	}
+	return nil
+}
+
+func (r *TeamRepo) LeaveTeam(ctx context.Context, teamID int64, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockTeam(ctx, tx, teamID); err != nil {
+		return err
+	}
+	if err := endMembership(ctx, tx, teamID, userID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *TeamRepo) RemoveMember(ctx context.Context, teamID int64, captainID, memberID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	captain, err := lockTeam(ctx, tx, teamID)
+	if err != nil {
+		return err
+	}
+	if captain != captainID {
+		return ErrNotTeamCaptain
+	}
+	if err := endMembership(ctx, tx, teamID, memberID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetCaptain передаёт роль капитана другому участнику команды.
+func (r *TeamRepo) SetCaptain(ctx context.Context, teamID int64, captainID, newCaptainID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	captain, err := lockTeam(ctx, tx, teamID)
+	if err != nil {
+		return err
+	}
+	if captain != captainID {
+		return ErrNotTeamCaptain
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE teams SET captain_id = $2 WHERE id = $1
+         AND EXISTS(SELECT 1 FROM team_memberships WHERE team_id = $1 AND user_id = $2 AND left_at IS NULL)`,
+		teamID, newCaptainID)
+	if err != nil {
+		return fmt.Errorf("set team captain: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotTeamMember
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *TeamRepo) SetInviteCode(ctx context.Context, teamID int64, captainID, code string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	captain, err := lockTeam(ctx, tx, teamID)
+	if err != nil {
+		return err
+	}
+	if captain != captainID {
+		return ErrNotTeamCaptain
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE teams SET invite_code = $2 WHERE id = $1`, teamID, code); err != nil {
+		return fmt.Errorf("set invite code: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// GetLeaderboard возвращает действующие команды по убыванию очков; равные очки делят место.
+func (r *TeamRepo) GetLeaderboard(ctx context.Context, limit, offset int) ([]model.TeamLeaderboardEntry, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`WITH totals AS (
+             SELECT t.id, t.name, `+teamMembersExpr+` AS members, `+teamPointsExpr+` AS points
+             FROM teams t WHERE t.disbanded_at IS NULL
+         )
+         SELECT CAST(RANK() OVER (ORDER BY points DESC) AS int), id, name, members, points
+         FROM totals
+         ORDER BY points DESC, id
+         LIMIT $2 OFFSET $3`,
+		teamEarningReasons, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query team leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []model.TeamLeaderboardEntry{}
+	for rows.Next() {
+		var entry model.TeamLeaderboardEntry
+		if err := rows.Scan(&entry.Position, &entry.TeamID, &entry.Name, &entry.MemberCount, &entry.Points); err != nil {
+			return nil, fmt.Errorf("scan team leaderboard entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (r *TeamRepo) CountTeams(ctx context.Context) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM teams WHERE disbanded_at IS NULL`).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count teams: %w", err)
+	}
+	return total, nil
+}
+
+func (r *TeamRepo) CreateChallenge(ctx context.Context, challenge *model.TeamChallenge) error {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM teams WHERE id = $1 AND disbanded_at IS NULL)`, challenge.TeamID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check team: %w", err)
+	}
+	if !exists {
+		return ErrTeamNotFound
+	}
+
+	var taskID sql.NullString
+	if challenge.Task != "" {
+		err := r.db.QueryRowContext(ctx, `SELECT id FROM tasks WHERE name = $1`, challenge.Task).Scan(&taskID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrTaskNotFound
+			}
+			return fmt.Errorf("find challenge task: %w", err)
+		}
+	}
+
+	challenge.CreatedAt = time.Now()
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO team_challenges (team_id, title, task_id, goal, reward_points, starts_at, ends_at, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`,
+		challenge.TeamID, challenge.Title, taskID, challenge.Goal, challenge.RewardPoints,
+		challenge.StartsAt, challenge.EndsAt, challenge.CreatedAt).Scan(&challenge.ID)
+	if err != nil {
+		return fmt.Errorf("create team challenge: %w", err)
+	}
+	return nil
+}
+
+// Прогресс челленджа c: задания, выполненные участниками в окне челленджа,
+// пока они состояли в команде
+const challengeProgressExpr = `(
+    SELECT COUNT(*) FROM task_completions tc
+    JOIN team_memberships m ON m.user_id = tc.user_id AND m.team_id = c.team_id
+                           AND tc.completed_at >= m.joined_at
+                           AND (m.left_at IS NULL OR tc.completed_at < m.left_at)
+    WHERE tc.completed_at >= c.starts_at AND tc.completed_at < c.ends_at
+      AND (c.task_id IS NULL OR tc.task_id = c.task_id)
+)`
+
+// ListChallenges возвращает челленджи команды, новые первыми, с текущим прогрессом.
+func (r *TeamRepo) ListChallenges(ctx context.Context, teamID int64) ([]model.TeamChallenge, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT c.id, c.team_id, c.title, COALESCE(tk.name, ''), c.goal, `+challengeProgressExpr+`,
+                c.reward_points, c.starts_at, c.ends_at, c.completed_at, c.created_at
+         FROM team_challenges c
+         LEFT JOIN tasks tk ON tk.id = c.task_id
+         WHERE c.team_id = $1
+         ORDER BY c.starts_at DESC, c.id DESC`,
+		teamID)
+	if err != nil {
+		return nil, fmt.Errorf("query team challenges: %w", err)
+	}
+	defer rows.Close()
+
+	challenges := []model.TeamChallenge{}
+	for rows.Next() {
+		var challenge model.TeamChallenge
+		var completedAt sql.NullTime
+		if err := rows.Scan(&challenge.ID, &challenge.TeamID, &challenge.Title, &challenge.Task, &challenge.Goal,
+			&challenge.Progress, &challenge.RewardPoints, &challenge.StartsAt, &challenge.EndsAt,
+			&completedAt, &challenge.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan team challenge: %w", err)
+		}
+		if completedAt.Valid {
+			challenge.CompletedAt = &completedAt.Time
+		}
+		challenges = append(challenges, challenge)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return challenges, nil
+}
+
+// CompleteReachedChallenges отмечает выполненными челленджи команды, достигшие цели,
+// и начисляет награду каждому текущему участнику. Строки челленджей блокируются,
+// поэтому награда выплачивается один раз даже при параллельных проверках.
+func (r *TeamRepo) CompleteReachedChallenges(ctx context.Context, teamID int64) ([]model.TeamChallenge, []model.BalanceChange, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	rows, err := tx.QueryContext(ctx,
+		`SELECT c.id, c.team_id, c.title, c.goal, `+challengeProgressExpr+`, c.reward_points,
+                c.starts_at, c.ends_at, c.created_at
+         FROM team_challenges c
+         WHERE c.team_id = $1 AND c.completed_at IS NULL AND c.starts_at <= $2 AND c.ends_at > $3
+         ORDER BY c.id
+         FOR UPDATE OF c`,
+		teamID, now, now.Add(-challengeGrace))
+	if err != nil {
+		return nil, nil, fmt.Errorf("lock team challenges: %w", err)
+	}
+	var reached []model.TeamChallenge
+	for rows.Next() {
+		var challenge model.TeamChallenge
+		if err := rows.Scan(&challenge.ID, &challenge.TeamID, &challenge.Title, &challenge.Goal, &challenge.Progress,
+			&challenge.RewardPoints, &challenge.StartsAt, &challenge.EndsAt, &challenge.CreatedAt); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan team challenge: %w", err)
+		}
+		if challenge.Progress >= challenge.Goal {
+			challenge.CompletedAt = &now
+			reached = append(reached, challenge)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+	if len(reached) == 0 {
+		return nil, nil, nil
+	}
+
+	var members []string
+	rows, err = tx.QueryContext(ctx,
+		`SELECT user_id FROM team_memberships WHERE team_id = $1 AND left_at IS NULL ORDER BY joined_at, id`, teamID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query team members: %w", err)
+	}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			rows.Close()
+			return nil, nil, fmt.Errorf("scan team member: %w", err)
+		}
+		members = append(members, userID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	var changes []model.BalanceChange
+	for _, challenge := range reached {
+		_, err := tx.ExecContext(ctx, `UPDATE team_challenges SET completed_at = $2 WHERE id = $1`, challenge.ID, now)
+		if err != nil {
+			return nil, nil, fmt.Errorf("complete team challenge: %w", err)
+		}
+		if challenge.RewardPoints <= 0 {
+			continue
+		}
+		for _, userID := range members {
+			change, err := applyPoints(ctx, tx, userID, challenge.RewardPoints, model.LedgerTeamChallenge,
+				strconv.FormatInt(challenge.ID, 10), now)
+			if err != nil {
+				return nil, nil, fmt.Errorf("award team challenge: %w", err)
+			}
+			changes = append(changes, *change)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return reached, changes, nil
+}
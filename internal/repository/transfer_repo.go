@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// serializableRetries — сколько раз повторять перевод при конфликте сериализации.
+const serializableRetries = 3
+
+var (
+	ErrRecipientNotFound   = errors.New("recipient not found")
+	ErrTransferLimit       = errors.New("daily transfer limit exceeded")
+	ErrIdempotencyConflict = errors.New("idempotency key was already used for a different transfer")
+)
+
+type TransferRepo struct {
+	db *sql.DB
+}
+
+type TransferRepository interface {
+	CreateTransfer(ctx context.Context, t *model.Transfer, idempotencyKey string, dailyAmount, dailyCount int) (*model.TransferResult, error)
+	ListTransfers(ctx context.Context, userID string, limit, offset int) ([]model.Transfer, error)
+	CountTransfers(ctx context.Context, userID string) (int, error)
+}
+
+func NewTransferRepo(db *sql.DB) *TransferRepo {
+	return &TransferRepo{db: db}
+}
+
+// CreateTransfer списывает очки у отправителя и начисляет получателю в одной
+// serializable-транзакции; при конфликте сериализации транзакция повторяется.
+// Повтор запроса с тем же ключом возвращает уже выполненный перевод.
+func (r *TransferRepo) CreateTransfer(ctx context.Context, t *model.Transfer, idempotencyKey string, dailyAmount, dailyCount int) (*model.TransferResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := r.createTransfer(ctx, t, idempotencyKey, dailyAmount, dailyCount)
+		if err == nil || !isSerializationFailure(err) || attempt == serializableRetries {
+			return result, err
+		}
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	var pqErr *pq.Error
+	// 40001 — serialization_failure, 23505 — параллельный запрос с тем же ключом
+	return errors.As(err, &pqErr) && (pqErr.Code == "40001" || pqErr.Code == "23505")
+}
+
+func (r *TransferRepo) createTransfer(ctx context.Context, t *model.Transfer, idempotencyKey string, dailyAmount, dailyCount int) (*model.TransferResult, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existing model.Transfer
+	err = tx.QueryRowContext(ctx,
+		`SELECT id, sender_id, recipient_id, amount, memo, created_at FROM transfers
+         WHERE sender_id = $1 AND idempotency_key = $2`,
+		t.SenderID, idempotencyKey).Scan(&existing.ID, &existing.SenderID, &existing.RecipientID,
+		&existing.Amount, &existing.Memo, &existing.CreatedAt)
+	switch {
+	case err == nil:
+		if existing.RecipientID != t.RecipientID || existing.Amount != t.Amount || existing.Memo != t.Memo {
+			return nil, ErrIdempotencyConflict
+		}
+		var balance int
+		if err := tx.QueryRowContext(ctx, `SELECT points FROM users WHERE id = $1`, t.SenderID).Scan(&balance); err != nil {
+			return nil, fmt.Errorf("get sender balance: %w", err)
+		}
+		return &model.TransferResult{Transfer: existing, Balance: balance, Replayed: true}, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("check idempotency key: %w", err)
+	}
+
+	var recipientExists bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, t.RecipientID).Scan(&recipientExists)
+	if err != nil {
+		return nil, fmt.Errorf("check recipient: %w", err)
+	}
+	if !recipientExists {
+		return nil, ErrRecipientNotFound
+	}
+
+	now := time.Now()
+	var sentAmount, sentCount int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(amount), 0), COUNT(*) FROM transfers
+         WHERE sender_id = $1 AND created_at > $2`,
+		t.SenderID, now.Add(-24*time.Hour)).Scan(&sentAmount, &sentCount)
+	if err != nil {
+		return nil, fmt.Errorf("sum daily transfers: %w", err)
+	}
+	if (dailyAmount > 0 && sentAmount+t.Amount > dailyAmount) || (dailyCount > 0 && sentCount >= dailyCount) {
+		return nil, ErrTransferLimit
+	}
+
+	transfer := *t
+	transfer.CreatedAt = now
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO transfers (sender_id, recipient_id, amount, memo, idempotency_key, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`,
+		transfer.SenderID, transfer.RecipientID, transfer.Amount, transfer.Memo, idempotencyKey, now,
+	).Scan(&transfer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+
+	reference := strconv.FormatInt(transfer.ID, 10)
+	debit, err := spendPoints(ctx, tx, transfer.SenderID, transfer.Amount, model.LedgerTransferOut, reference, now)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := restorePoints(ctx, tx, transfer.RecipientID, transfer.Amount, model.LedgerTransferIn, model.LedgerTransferOut, reference, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return &model.TransferResult{
+		Transfer: transfer,
+		Balance:  debit.Balance,
+		Changes:  []model.BalanceChange{*debit, *credit},
+	}, nil
+}
+
+// ListTransfers возвращает отправленные и полученные пользователем переводы, новые первыми.
+func (r *TransferRepo) ListTransfers(ctx context.Context, userID string, limit, offset int) ([]model.Transfer, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, sender_id, recipient_id, amount, memo, created_at FROM transfers
+         WHERE sender_id = $1 OR recipient_id = $1
+         ORDER BY created_at DESC, id DESC
+         LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query transfers: %w", err)
+	}
+	defer rows.Close()
+
+	transfers := []model.Transfer{}
+	for rows.Next() {
+		var t model.Transfer
+		if err := rows.Scan(&t.ID, &t.SenderID, &t.RecipientID, &t.Amount, &t.Memo, &t.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan transfer: %w", err)
+		}
+		transfers = append(transfers, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return transfers, nil
+}
+
+func (r *TransferRepo) CountTransfers(ctx context.Context, userID string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM transfers WHERE sender_id = $1 OR recipient_id = $1`,
+		userID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count transfers: %w", err)
+	}
+	return total, nil
+}
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -18,10 +19,22 @@ type UserRepository interface {
 	GetUserByID(ctx context.Context, id string) (*model.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
 	UpdateUserPoints(ctx context.Context, id string, points int) error
-	SetReferrer(ctx context.Context, id, referrerID string) error
 	GetReferrals(ctx context.Context, referrerID string) ([]model.User, error)
-	GetLeaderboard(ctx context.Context, limit int) ([]model.LeaderboardEntry, error)
+	GetLeaderboard(ctx context.Context, q model.LeaderboardQuery) ([]model.LeaderboardEntry, error)
+	GetUserRank(ctx context.Context, id string, q model.LeaderboardQuery) (position, points int, err error)
+	CountRanked(ctx context.Context, q model.LeaderboardQuery) (int, error)
+	GetScores(ctx context.Context, changedSince *time.Time) ([]model.UserScore, error)
+	GetScore(ctx context.Context, userID string) (*model.UserScore, error)
 	EmailExists(ctx context.Context, email string) (bool, error)
+	IsAdmin(ctx context.Context, id string) (bool, error)
+	AddFriend(ctx context.Context, userID, friendID string) error
+	RemoveFriend(ctx context.Context, userID, friendID string) error
+	ListFriends(ctx context.Context, userID string) ([]model.Friend, error)
+	SetCohort(ctx context.Context, userID, kind, value string) error
+	SetEmailVerified(ctx context.Context, userID string, verified bool) error
+	SetLeaderboardVisibility(ctx context.Context, userID string, visibility model.LeaderboardVisibility) (*model.UserScore, error)
+	ListHidden(ctx context.Context, limit, offset int) ([]model.HiddenUser, error)
+	CountHidden(ctx context.Context) (int, error)
 }
 
 func NewUserRepo(db *sql.DB) *UserRepo {
@@ -29,24 +42,45 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 }
 
 func (r *UserRepo) CreateUser(ctx context.Context, user *model.User) error {
-	query := `INSERT INTO users (id, name, email, password, points, created_at, updated_at) 
-              VALUES ($1, $2, $3, $4, $5, $6, $7)`
-	_, err := r.db.ExecContext(ctx, query,
-		user.ID, user.Name, user.Email, user.Password, user.Points, user.CreatedAt, user.UpdatedAt)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (id, name, email, password, points, created_at, updated_at,
+                  normalized_email, registration_ip, registration_user_agent, device_id) 
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))`
+	_, err = tx.ExecContext(ctx, query,
+		user.ID, user.Name, user.Email, user.Password, user.Points, user.CreatedAt, user.UpdatedAt,
+		user.NormalizedEmail, user.Registration.IP, user.Registration.UserAgent, user.Registration.DeviceID)
 	if err != nil {
 		return fmt.Errorf("create user: %w", err)
 	}
+
+	if user.Registration.Country != "" {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO user_cohorts (user_id, kind, value) VALUES ($1, $2, $3)`,
+			user.ID, model.CohortCountry, user.Registration.Country)
+		if err != nil {
+			return fmt.Errorf("set user country: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
 	return nil
 }
 
 func (r *UserRepo) GetUserByID(ctx context.Context, id string) (*model.User, error) {
-	query := `SELECT id, name, email, password, points, referrer, created_at, updated_at 
+	query := `SELECT id, name, email, password, points, xp, referrer, created_at, updated_at 
               FROM users WHERE id = $1`
 	row := r.db.QueryRowContext(ctx, query, id)
 
 	var user model.User
 	var referrer sql.NullString
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Points,
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Points, &user.XP,
 		&referrer, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("get user by id: %w", err)
@@ -60,116 +94,137 @@ func (r *UserRepo) GetUserByID(ctx context.Context, id string) (*model.User, err
 }
 
 func (r *UserRepo) UpdateUserPoints(ctx context.Context, id string, points int) error {
-	query := `UPDATE users SET points = points + $1 WHERE id = $2`
-	result, err := r.db.ExecContext(ctx, query, points, id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to update user points: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
+	if _, err := applyPoints(ctx, tx, id, points, model.LedgerAdjustment, "", time.Now()); err != nil {
+		return err
 	}
 
-	if rowsAffected == 0 {
-		return errors.New("user not found")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-func (r *UserRepo) SetReferrer(ctx context.Context, userId, referrerId string) error {
-	if userId == referrerId {
-		return errors.New("user cannot be their own referrer")
+// leaderboardScores строит CTE scores(id, name, score, reached_at) для запроса рейтинга.
+// За всё время счёт — текущий баланс в валюте q.Currency; для периода — сумма заработка
+// (rankingReasons) из журнала, без переводов, возвратов, стартового баланса и сезонных наград.
+func leaderboardScores(q model.LeaderboardQuery) (string, []any) {
+	var args []any
+	conds := []string{visibilityCondition(q, &args)}
+	if scope := scopeCondition(q, &args); scope != "" {
+		conds = append(conds, scope)
 	}
 
-	var userExists bool
-	err := r.db.QueryRowContext(ctx,
-		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`,
-		userId).Scan(&userExists)
-	if err != nil {
-		return fmt.Errorf("failed to check user existence: %w", err)
-	}
-	if !userExists {
-		return errors.New("user not found")
+	if q.Since == nil && q.Until == nil {
+		score, reachedAt := "u.points", "u.points_reached_at"
+		if q.Currency == model.CurrencyXP {
+			score, reachedAt = "u.xp", "u.xp_reached_at"
+		}
+		return `scores AS (
+            SELECT u.id, u.name, ` + score + ` AS score, ` + reachedAt + ` AS reached_at
+            FROM users u
+            WHERE ` + strings.Join(conds, " AND ") + `
+        )`, args
 	}
 
-	var referrerExists bool
-	err = r.db.QueryRowContext(ctx,
-		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`,
-		referrerId).Scan(&referrerExists)
-	if err != nil {
-		return fmt.Errorf("failed to check referrer existence: %w", err)
+	currency := q.Currency
+	if currency == "" {
+		currency = model.CurrencyPoints
 	}
-	if !referrerExists {
-		return errors.New("referrer not found")
-	}
-
-	var hasReferrer bool
-	err = r.db.QueryRowContext(ctx,
-		`SELECT referrer IS NOT NULL FROM users WHERE id = $1`,
-		userId).Scan(&hasReferrer)
-	if err != nil {
-		return fmt.Errorf("failed to check referrer status: %w", err)
+	args = append(args, currency, rankingReasons)
+	conds = append(conds, fmt.Sprintf("l.currency = $%d", len(args)-1), "l.amount > 0", fmt.Sprintf("l.reason = ANY($%d)", len(args)))
+	if q.Since != nil {
+		args = append(args, *q.Since)
+		conds = append(conds, fmt.Sprintf("l.created_at >= $%d", len(args)))
 	}
-	if hasReferrer {
-		return errors.New("referrer already set")
+	if q.Until != nil {
+		args = append(args, *q.Until)
+		conds = append(conds, fmt.Sprintf("l.created_at < $%d", len(args)))
 	}
 
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
+	return `scores AS (
+            SELECT u.id, u.name, SUM(l.amount) AS score, MAX(l.created_at) AS reached_at
+            FROM points_ledger l
+            JOIN users u ON u.id = l.user_id
+            WHERE ` + strings.Join(conds, " AND ") + `
+            GROUP BY u.id, u.name
+        )`, args
+}
 
-	_, err = tx.ExecContext(ctx,
-		`UPDATE users SET referrer = $1, updated_at = $2 WHERE id = $3`,
-		referrerId, time.Now(), userId)
-	if err != nil {
-		return fmt.Errorf("failed to set referrer: %w", err)
+// visibilityCondition убирает из рейтинга скрытых пользователей; пользователь
+// с теневым баном остаётся в рейтинге, который смотрит он сам.
+func visibilityCondition(q model.LeaderboardQuery, args *[]any) string {
+	if q.ViewerID == "" {
+		return `u.leaderboard_visibility = 'visible'`
 	}
+	*args = append(*args, q.ViewerID)
+	return fmt.Sprintf(`(u.leaderboard_visibility = 'visible'
+            OR (u.leaderboard_visibility = 'shadow' AND u.id = $%d))`, len(*args))
+}
 
-	_, err = tx.ExecContext(ctx,
-		`UPDATE users SET points = points + 100, updated_at = $1 WHERE id = $2`,
-		time.Now(), referrerId)
-	if err != nil {
-		return fmt.Errorf("failed to add referral bonus: %w", err)
-	}
-	_, err = tx.ExecContext(ctx,
-		`INSERT INTO user_tasks (user_id, task_id, completed_at)
-         VALUES ($1, '3', $2)  -- '3' это ID реферального задания
-         ON CONFLICT (user_id, task_id) DO UPDATE SET completed_at = $2`,
-		userId, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to complete referral task: %w", err)
+// scopeCondition возвращает условие на users u для q.Scope; сам пользователь
+// всегда входит в свой круг, чтобы видеть себя рядом с друзьями.
+func scopeCondition(q model.LeaderboardQuery, args *[]any) string {
+	if q.Scope == "" || q.Scope == model.ScopeGlobal {
+		return ""
 	}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	*args = append(*args, q.ScopeUserID)
+	me := len(*args)
+
+	switch q.Scope {
+	case model.ScopeFriends:
+		return fmt.Sprintf(`(u.id = $%[1]d OR u.id IN (SELECT friend_id FROM friendships WHERE user_id = $%[1]d))`, me)
+	case model.ScopeNetwork:
+		return fmt.Sprintf(`(u.id = $%[1]d OR u.referrer = $%[1]d
+            OR u.id = (SELECT referrer FROM users WHERE id = $%[1]d))`, me)
+	case model.ScopeCohort:
+		if q.Cohort == model.CohortSignupMonth {
+			return fmt.Sprintf(`date_trunc('month', u.created_at) =
+            (SELECT date_trunc('month', created_at) FROM users WHERE id = $%d)`, me)
+		}
+		*args = append(*args, q.Cohort)
+		return fmt.Sprintf(`u.id IN (
+            SELECT c.user_id FROM user_cohorts c
+            JOIN user_cohorts mine ON mine.kind = c.kind AND mine.value = c.value
+            WHERE mine.user_id = $%d AND mine.kind = $%d)`, me, len(*args))
+	default:
+		return "FALSE"
 	}
-
-	return nil
 }
 
-func (r *UserRepo) GetLeaderboard(ctx context.Context, limit int) ([]model.LeaderboardEntry, error) {
-	query := `
-        SELECT 
-            id, 
-            name, 
-            points,
-            ROW_NUMBER() OVER (ORDER BY points DESC) as position
-        FROM users 
-        ORDER BY points DESC 
-        LIMIT $1
-    `
-
-	rows, err := r.db.QueryContext(ctx, query, limit)
+// rankedScores нумерует scores в едином порядке рейтинга: при равенстве очков выше тот,
+// кто набрал их раньше, затем по id, чтобы позиции не «прыгали» между запросами.
+const rankedScores = `ranked AS (
+            SELECT id, name, score,
+                   ROW_NUMBER() OVER (ORDER BY score DESC, reached_at ASC, id ASC) AS position
+            FROM scores
+        )`
+
+func (r *UserRepo) GetLeaderboard(ctx context.Context, q model.LeaderboardQuery) ([]model.LeaderboardEntry, error) {
+	scores, args := leaderboardScores(q)
+	query := fmt.Sprintf(`
+        WITH %s, %s
+        SELECT id, name, score, position
+        FROM ranked
+        ORDER BY position
+        LIMIT $%d OFFSET $%d
+    `, scores, rankedScores, len(args)+1, len(args)+2)
+	args = append(args, q.Limit, q.Offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
 	}
 	defer rows.Close()
 
-	var leaderboard []model.LeaderboardEntry
+	leaderboard := []model.LeaderboardEntry{}
 	for rows.Next() {
 		var entry model.LeaderboardEntry
 		if err := rows.Scan(
@@ -190,12 +245,72 @@ func (r *UserRepo) GetLeaderboard(ctx context.Context, limit int) ([]model.Leade
 	return leaderboard, nil
 }
 
+// GetUserRank возвращает позицию и счёт пользователя; sql.ErrNoRows — пользователь вне рейтинга.
+func (r *UserRepo) GetUserRank(ctx context.Context, id string, q model.LeaderboardQuery) (position, points int, err error) {
+	scores, args := leaderboardScores(q)
+	query := fmt.Sprintf(`
+        WITH %s, %s
+        SELECT position, score FROM ranked WHERE id = $%d
+    `, scores, rankedScores, len(args)+1)
+	args = append(args, id)
+
+	err = r.db.QueryRowContext(ctx, query, args...).Scan(&position, &points)
+	if err != nil {
+		return 0, 0, fmt.Errorf("get user rank: %w", err)
+	}
+	return position, points, nil
+}
+
+func (r *UserRepo) CountRanked(ctx context.Context, q model.LeaderboardQuery) (int, error) {
+	scores, args := leaderboardScores(q)
+	query := fmt.Sprintf(`WITH %s SELECT COUNT(*) FROM scores`, scores)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count ranked users: %w", err)
+	}
+	return total, nil
+}
+
+// GetScores возвращает опыт видимых в рейтинге пользователей — всех или только
+// изменившийся после changedSince.
+func (r *UserRepo) GetScores(ctx context.Context, changedSince *time.Time) ([]model.UserScore, error) {
+	query := `SELECT id, name, xp, xp_reached_at FROM users WHERE leaderboard_visibility = 'visible'`
+	var args []any
+	if changedSince != nil {
+		query += ` AND xp_reached_at > $1`
+		args = append(args, *changedSince)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query user scores: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []model.UserScore
+	for rows.Next() {
+		var score model.UserScore
+		if err := rows.Scan(&score.UserID, &score.Name, &score.XP, &score.ReachedAt); err != nil {
+			return nil, fmt.Errorf("scan user score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return scores, nil
+}
+
 func (r *UserRepo) GetReferrals(ctx context.Context, referrerID string) ([]model.User, error) {
 	query := `
-        SELECT u.id, u.name, u.email, u.password, u.points, u.referrer, u.created_at, u.updated_at
+        SELECT u.id, u.name, u.email, u.points, u.xp, u.referrer, u.created_at, u.updated_at
         FROM users u
         JOIN referrals r ON u.id = r.referee_id
         WHERE r.referrer_id = $1
+        ORDER BY r.date DESC
     `
 	rows, err := r.db.QueryContext(ctx, query, referrerID)
 	if err != nil {
@@ -207,7 +322,7 @@ func (r *UserRepo) GetReferrals(ctx context.Context, referrerID string) ([]model
 	for rows.Next() {
 		var user model.User
 		var referrer sql.NullString
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Points, &referrer, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Points, &user.XP, &referrer, &user.CreatedAt, &user.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan referral user: %w", err)
 		}
 		if referrer.Valid {
@@ -224,13 +339,13 @@ func (r *UserRepo) GetReferrals(ctx context.Context, referrerID string) ([]model
 }
 
 func (r *UserRepo) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
-	query := `SELECT id, name, email, password, points, referrer, created_at, updated_at 
+	query := `SELECT id, name, email, password, points, xp, referrer, created_at, updated_at 
               FROM users WHERE email = $1`
 	row := r.db.QueryRowContext(ctx, query, email)
 
 	var user model.User
 	var referrer sql.NullString
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Points,
+	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Password, &user.Points, &user.XP,
 		&referrer, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -255,3 +370,202 @@ func (r *UserRepo) EmailExists(ctx context.Context, email string) (bool, error)
 	}
 	return exists, nil
 }
+
+func (r *UserRepo) IsAdmin(ctx context.Context, id string) (bool, error) {
+	var isAdmin bool
+	err := r.db.QueryRowContext(ctx, `SELECT is_admin FROM users WHERE id = $1`, id).Scan(&isAdmin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check admin role: %w", err)
+	}
+	return isAdmin, nil
+}
+
+// AddFriend добавляет friendID в список друзей userID; повторное добавление не ошибка.
+// sql.ErrNoRows — такого пользователя нет.
+func (r *UserRepo) AddFriend(ctx context.Context, userID, friendID string) error {
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO friendships (user_id, friend_id, created_at)
+         SELECT $1, id, $3 FROM users WHERE id = $2
+         ON CONFLICT (user_id, friend_id) DO NOTHING`,
+		userID, friendID, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to add friend: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, friendID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check friend existence: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("friend %s: %w", friendID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+func (r *UserRepo) RemoveFriend(ctx context.Context, userID, friendID string) error {
+	_, err := r.db.ExecContext(ctx,
+		`DELETE FROM friendships WHERE user_id = $1 AND friend_id = $2`,
+		userID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to remove friend: %w", err)
+	}
+	return nil
+}
+
+func (r *UserRepo) ListFriends(ctx context.Context, userID string) ([]model.Friend, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT u.id, u.name, u.points, f.created_at
+         FROM friendships f
+         JOIN users u ON u.id = f.friend_id
+         WHERE f.user_id = $1
+         ORDER BY u.points DESC, u.id`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query friends: %w", err)
+	}
+	defer rows.Close()
+
+	friends := []model.Friend{}
+	for rows.Next() {
+		var friend model.Friend
+		if err := rows.Scan(&friend.UserID, &friend.Name, &friend.Points, &friend.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan friend: %w", err)
+		}
+		friends = append(friends, friend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return friends, nil
+}
+
+// SetCohort задаёт значение когорты kind для пользователя; пустое value убирает пользователя из когорты.
+// sql.ErrNoRows — такого пользователя нет.
+func (r *UserRepo) SetCohort(ctx context.Context, userID, kind, value string) error {
+	if value == "" {
+		_, err := r.db.ExecContext(ctx,
+			`DELETE FROM user_cohorts WHERE user_id = $1 AND kind = $2`,
+			userID, kind)
+		if err != nil {
+			return fmt.Errorf("failed to remove cohort: %w", err)
+		}
+		return nil
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_cohorts (user_id, kind, value)
+         SELECT id, $2, $3 FROM users WHERE id = $1
+         ON CONFLICT (user_id, kind) DO UPDATE SET value = EXCLUDED.value`,
+		userID, kind, value)
+	if err != nil {
+		return fmt.Errorf("failed to set cohort: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user %s: %w", userID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// SetEmailVerified отмечает email пользователя подтверждённым (повторная отметка сохраняет
+// исходное время) или снимает отметку. sql.ErrNoRows — такого пользователя нет.
+func (r *UserRepo) SetEmailVerified(ctx context.Context, userID string, verified bool) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE users SET email_verified_at = CASE WHEN $1 THEN COALESCE(email_verified_at, $2) END
+         WHERE id = $3`,
+		verified, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set email verification: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user %s: %w", userID, sql.ErrNoRows)
+	}
+	return nil
+}
+
+// GetScore возвращает опыт пользователя; sql.ErrNoRows — пользователя нет или он скрыт из рейтингов.
+func (r *UserRepo) GetScore(ctx context.Context, userID string) (*model.UserScore, error) {
+	var score model.UserScore
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, xp, xp_reached_at FROM users WHERE id = $1 AND leaderboard_visibility = 'visible'`,
+		userID).Scan(&score.UserID, &score.Name, &score.XP, &score.ReachedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &score, nil
+}
+
+// SetLeaderboardVisibility меняет видимость пользователя в рейтингах и возвращает его текущий опыт.
+// sql.ErrNoRows — такого пользователя нет.
+func (r *UserRepo) SetLeaderboardVisibility(ctx context.Context, userID string, visibility model.LeaderboardVisibility) (*model.UserScore, error) {
+	var score model.UserScore
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE users SET leaderboard_visibility = $1 WHERE id = $2
+         RETURNING id, name, xp, xp_reached_at`,
+		visibility, userID).Scan(&score.UserID, &score.Name, &score.XP, &score.ReachedAt)
+	if err != nil {
+		return nil, fmt.Errorf("set leaderboard visibility: %w", err)
+	}
+	return &score, nil
+}
+
+func (r *UserRepo) ListHidden(ctx context.Context, limit, offset int) ([]model.HiddenUser, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, points, leaderboard_visibility FROM users
+         WHERE leaderboard_visibility <> 'visible'
+         ORDER BY points DESC, id
+         LIMIT $1 OFFSET $2`,
+		limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query hidden users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []model.HiddenUser{}
+	for rows.Next() {
+		var user model.HiddenUser
+		if err := rows.Scan(&user.UserID, &user.Name, &user.Points, &user.Visibility); err != nil {
+			return nil, fmt.Errorf("failed to scan hidden user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepo) CountHidden(ctx context.Context) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users WHERE leaderboard_visibility <> 'visible'`).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count hidden users: %w", err)
+	}
+	return total, nil
+}
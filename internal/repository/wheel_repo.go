@@ -0,0 +1,341 @@
+package repository
+
+import (
+	"Test/internal/fairness"
+	"Test/internal/model"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+var ErrWheelNotConfigured = errors.New("wheel has no prize table")
+
+type WheelRepo struct {
+	db *sql.DB
+}
+
+type WheelRepository interface {
+	GetActiveTable(ctx context.Context) (*model.WheelTable, error)
+	CreateTable(ctx context.Context, prizes []model.WheelPrize) (*model.WheelTable, error)
+	GetActiveSeed(ctx context.Context, userID string, candidate *model.WheelSeed) (*model.WheelSeed, error)
+	RotateSeed(ctx context.Context, userID string, next *model.WheelSeed) (*model.WheelSeed, error)
+	Spin(ctx context.Context, userID string, candidate *model.WheelSeed, cost int, dayStart time.Time, freePerDay int) (*model.WheelSpin, error)
+	ListSpins(ctx context.Context, userID string, limit, offset int) ([]model.WheelSpin, error)
+	CountSpins(ctx context.Context, userID string) (int, error)
+	GetTableStats(ctx context.Context, tableID int64) (*model.WheelReport, map[int]int, error)
+}
+
+func NewWheelRepo(db *sql.DB) *WheelRepo {
+	return &WheelRepo{db: db}
+}
+
+func (r *WheelRepo) GetActiveTable(ctx context.Context) (*model.WheelTable, error) {
+	return activeWheelTable(ctx, r.db)
+}
+
+// activeWheelTable возвращает последнюю версию таблицы призов.
+func activeWheelTable(ctx context.Context, db querier) (*model.WheelTable, error) {
+	var table model.WheelTable
+	var prizes []byte
+	err := db.QueryRowContext(ctx,
+		`SELECT t.id, t.created_at,
+                json_agg(json_build_object('position', p.position, 'label', p.label,
+                                           'points', p.points, 'weight', p.weight) ORDER BY p.position)
+         FROM wheel_tables t
+         JOIN wheel_prizes p ON p.table_id = t.id
+         GROUP BY t.id
+         ORDER BY t.id DESC
+         LIMIT 1`).Scan(&table.ID, &table.CreatedAt, &prizes)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWheelNotConfigured
+		}
+		return nil, fmt.Errorf("get wheel table: %w", err)
+	}
+	if err := json.Unmarshal(prizes, &table.Prizes); err != nil {
+		return nil, fmt.Errorf("decode wheel prizes: %w", err)
+	}
+	return &table, nil
+}
+
+// CreateTable сохраняет новую версию таблицы призов; она сразу становится активной.
+func (r *WheelRepo) CreateTable(ctx context.Context, prizes []model.WheelPrize) (*model.WheelTable, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	table := &model.WheelTable{CreatedAt: time.Now()}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wheel_tables (created_at) VALUES ($1) RETURNING id`, table.CreatedAt).Scan(&table.ID)
+	if err != nil {
+		return nil, fmt.Errorf("create wheel table: %w", err)
+	}
+
+	for i, prize := range prizes {
+		prize.Position = i + 1
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO wheel_prizes (table_id, position, label, points, weight) VALUES ($1, $2, $3, $4, $5)`,
+			table.ID, prize.Position, prize.Label, prize.Points, prize.Weight)
+		if err != nil {
+			return nil, fmt.Errorf("create wheel prize: %w", err)
+		}
+		table.Prizes = append(table.Prizes, prize)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return table, nil
+}
+
+// GetActiveSeed возвращает текущую пару сидов пользователя, создавая её из candidate,
+// если пары ещё нет. Серверный сид активной пары не возвращается.
+func (r *WheelRepo) GetActiveSeed(ctx context.Context, userID string, candidate *model.WheelSeed) (*model.WheelSeed, error) {
+	seed, err := lockActiveSeed(ctx, r.db, userID, candidate, false)
+	if err != nil {
+		return nil, err
+	}
+	seed.ServerSeed = ""
+	return seed, nil
+}
+
+func lockActiveSeed(ctx context.Context, db querier, userID string, candidate *model.WheelSeed, forUpdate bool) (*model.WheelSeed, error) {
+	_, err := db.ExecContext(ctx,
+		`INSERT INTO wheel_seeds (user_id, server_seed, server_seed_hash, client_seed, created_at)
+         VALUES ($1, $2, $3, $4, $5)
+         ON CONFLICT (user_id) WHERE revealed_at IS NULL DO NOTHING`,
+		userID, candidate.ServerSeed, candidate.ServerSeedHash, candidate.ClientSeed, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("create wheel seed: %w", err)
+	}
+
+	query := `SELECT id, server_seed, server_seed_hash, client_seed, nonce, created_at
+              FROM wheel_seeds WHERE user_id = $1 AND revealed_at IS NULL`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	var seed model.WheelSeed
+	err = db.QueryRowContext(ctx, query, userID).Scan(&seed.ID, &seed.ServerSeed, &seed.ServerSeedHash,
+		&seed.ClientSeed, &seed.Nonce, &seed.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("get wheel seed: %w", err)
+	}
+	return &seed, nil
+}
+
+// RotateSeed раскрывает текущую пару сидов и делает next активной. Возвращает
+// раскрытую пару с серверным сидом.
+func (r *WheelRepo) RotateSeed(ctx context.Context, userID string, next *model.WheelSeed) (*model.WheelSeed, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Без активной пары раскрывается только что созданная из next
+	revealed, err := lockActiveSeed(ctx, tx, userID, next, true)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `UPDATE wheel_seeds SET revealed_at = $2 WHERE id = $1`, revealed.ID, now); err != nil {
+		return nil, fmt.Errorf("reveal wheel seed: %w", err)
+	}
+	revealed.RevealedAt = &now
+
+	next.CreatedAt = now
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wheel_seeds (user_id, server_seed, server_seed_hash, client_seed, created_at)
+         VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+		userID, next.ServerSeed, next.ServerSeedHash, next.ClientSeed, now).Scan(&next.ID)
+	if err != nil {
+		return nil, fmt.Errorf("create wheel seed: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return revealed, nil
+}
+
+// Spin разыгрывает приз по активной паре сидов и текущему nonce. Первые freePerDay
+// вращений с dayStart бесплатны, остальные стоят cost очков. Строка пары сидов
+// блокируется, поэтому nonce не повторяется при параллельных вращениях.
+func (r *WheelRepo) Spin(ctx context.Context, userID string, candidate *model.WheelSeed, cost int, dayStart time.Time, freePerDay int) (*model.WheelSpin, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	seed, err := lockActiveSeed(ctx, tx, userID, candidate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := activeWheelTable(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var freeUsed int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM wheel_spins WHERE user_id = $1 AND free AND created_at >= $2`,
+		userID, dayStart).Scan(&freeUsed)
+	if err != nil {
+		return nil, fmt.Errorf("count free spins: %w", err)
+	}
+
+	spin := &model.WheelSpin{
+		UserID:         userID,
+		SeedID:         seed.ID,
+		ServerSeedHash: seed.ServerSeedHash,
+		ClientSeed:     seed.ClientSeed,
+		Nonce:          seed.Nonce,
+		TableID:        table.ID,
+		Cost:           cost,
+		Free:           freeUsed < freePerDay,
+		CreatedAt:      time.Now(),
+	}
+	if spin.Free {
+		spin.Cost = 0
+	}
+
+	weights := make([]int, len(table.Prizes))
+	for i, prize := range table.Prizes {
+		weights[i] = prize.Weight
+	}
+	digest, value := fairness.Roll(seed.ServerSeed, seed.ClientSeed, seed.Nonce)
+	spin.Digest = digest
+	spin.Prize = table.Prizes[fairness.Pick(value, weights)]
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO wheel_spins (user_id, seed_id, nonce, digest, table_id, position, points, cost, free, created_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		userID, spin.SeedID, spin.Nonce, spin.Digest, spin.TableID, spin.Prize.Position,
+		spin.Prize.Points, spin.Cost, spin.Free, spin.CreatedAt).Scan(&spin.ID)
+	if err != nil {
+		return nil, fmt.Errorf("record wheel spin: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE wheel_seeds SET nonce = nonce + 1 WHERE id = $1`, seed.ID); err != nil {
+		return nil, fmt.Errorf("advance wheel nonce: %w", err)
+	}
+
+	reference := strconv.FormatInt(spin.ID, 10)
+	if spin.Cost > 0 {
+		change, err := spendPoints(ctx, tx, userID, spin.Cost, model.LedgerWheelSpin, reference, spin.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		spin.Changes = append(spin.Changes, *change)
+	}
+	if spin.Prize.Points > 0 {
+		change, err := applyPoints(ctx, tx, userID, spin.Prize.Points, model.LedgerWheelPrize, reference, spin.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		spin.Changes = append(spin.Changes, *change)
+	}
+
+	if n := len(spin.Changes); n > 0 {
+		spin.Balance = spin.Changes[n-1].Balance
+	} else if err := tx.QueryRowContext(ctx, `SELECT points FROM users WHERE id = $1`, userID).Scan(&spin.Balance); err != nil {
+		return nil, fmt.Errorf("get balance: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return spin, nil
+}
+
+// ListSpins возвращает журнал вращений пользователя (или всех при пустом userID), новые первыми.
+// Серверный сид показывается только для уже раскрытых пар.
+func (r *WheelRepo) ListSpins(ctx context.Context, userID string, limit, offset int) ([]model.WheelSpin, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT s.id, s.user_id, s.seed_id, ws.server_seed_hash,
+                CASE WHEN ws.revealed_at IS NOT NULL THEN ws.server_seed ELSE '' END,
+                ws.client_seed, s.nonce, s.digest, s.table_id, s.position, p.label, s.points, p.weight,
+                s.cost, s.free, s.created_at
+         FROM wheel_spins s
+         JOIN wheel_seeds ws ON ws.id = s.seed_id
+         JOIN wheel_prizes p ON p.table_id = s.table_id AND p.position = s.position
+         WHERE $1 = '' OR s.user_id = $1
+         ORDER BY s.created_at DESC, s.id DESC
+         LIMIT $2 OFFSET $3`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("query wheel spins: %w", err)
+	}
+	defer rows.Close()
+
+	spins := []model.WheelSpin{}
+	for rows.Next() {
+		var spin model.WheelSpin
+		if err := rows.Scan(&spin.ID, &spin.UserID, &spin.SeedID, &spin.ServerSeedHash, &spin.ServerSeed,
+			&spin.ClientSeed, &spin.Nonce, &spin.Digest, &spin.TableID, &spin.Prize.Position, &spin.Prize.Label,
+			&spin.Prize.Points, &spin.Prize.Weight, &spin.Cost, &spin.Free, &spin.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan wheel spin: %w", err)
+		}
+		spins = append(spins, spin)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return spins, nil
+}
+
+func (r *WheelRepo) CountSpins(ctx context.Context, userID string) (int, error) {
+	var total int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM wheel_spins WHERE $1 = '' OR user_id = $1`, userID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("count wheel spins: %w", err)
+	}
+	return total, nil
+}
+
+// GetTableStats возвращает фактические итоги вращений по таблице и число выпадений каждого приза.
+func (r *WheelRepo) GetTableStats(ctx context.Context, tableID int64) (*model.WheelReport, map[int]int, error) {
+	report := &model.WheelReport{TableID: tableID}
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COUNT(*) FILTER (WHERE free), COALESCE(SUM(cost), 0), COALESCE(SUM(points), 0)
+         FROM wheel_spins WHERE table_id = $1`,
+		tableID).Scan(&report.Spins, &report.FreeSpins, &report.PointsSpent, &report.PointsPaid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get wheel totals: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT position, COUNT(*) FROM wheel_spins WHERE table_id = $1 GROUP BY position`, tableID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query wheel hits: %w", err)
+	}
+	defer rows.Close()
+
+	hits := make(map[int]int)
+	for rows.Next() {
+		var position, count int
+		if err := rows.Scan(&position, &count); err != nil {
+			return nil, nil, fmt.Errorf("scan wheel hits: %w", err)
+		}
+		hits[position] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return report, hits, nil
+}
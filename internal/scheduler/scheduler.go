@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Every запускает job сразу и затем с заданным интервалом, пока не отменён ctx.
+// Ошибки job логируются и не останавливают расписание.
+func Every(ctx context.Context, interval time.Duration, name string, job func(context.Context) error) {
+	if interval <= 0 {
+		log.Printf("Job %s disabled: non-positive interval", name)
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := job(ctx); err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// badgeBuffer — буфер подписки движка значков на шину событий
+const badgeBuffer = 256
+
+var (
+	ErrInvalidBadge = errors.New("invalid badge")
+
+	badgeIDPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+)
+
+// badgeTriggers — какие правила пересчитываются по событию для его пользователя.
+var badgeTriggers = map[events.Type][]model.BadgeRule{
+	events.TaskCompleted: {model.BadgeRuleTasksCompleted, model.BadgeRuleTaskStreak},
+	events.ReferralPaid:  {model.BadgeRuleReferrals},
+	events.CheckedIn:     {model.BadgeRuleCheckinStreak},
+}
+
+type BadgeService struct {
+	repo repository.BadgeRepository
+	bus  *events.Bus
+}
+
+func NewBadgeService(repo repository.BadgeRepository, bus *events.Bus) *BadgeService {
+	return &BadgeService{repo: repo, bus: bus}
+}
+
+// Run проверяет правила значков по событиям шины до отмены ctx. Выдача идемпотентна,
+// поэтому при отставании от шины подписка просто восстанавливается; пропущенные
+// значки будут выданы при следующем событии того же пользователя.
+func (s *BadgeService) Run(ctx context.Context) {
+	for {
+		sub := s.bus.Subscribe(badgeEvent, badgeBuffer)
+		s.consume(ctx, sub)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Badge engine fell behind the event bus, resubscribing")
+	}
+}
+
+func badgeEvent(event events.Event) bool {
+	_, ok := badgeTriggers[event.Type]
+	return ok || event.Type == events.SeasonEnded
+}
+
+func (s *BadgeService) consume(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if err := s.handle(ctx, event); err != nil {
+				log.Printf("Badge evaluation for %s event failed: %v", event.Type, err)
+			}
+		}
+	}
+}
+
+func (s *BadgeService) handle(ctx context.Context, event events.Event) error {
+	var awarded []model.UserBadge
+	var err error
+	if event.Type == events.SeasonEnded {
+		season, ok := event.Data.(*model.Season)
+		if !ok {
+			return fmt.Errorf("unexpected season event data %T", event.Data)
+		}
+		awarded, err = s.repo.AwardSeasonRanks(ctx, season.ID)
+	} else {
+		awarded, err = s.repo.AwardEligible(ctx, event.UserID, badgeTriggers[event.Type])
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, badge := range awarded {
+		s.bus.Publish(events.BadgeAwarded, badge.UserID, badge)
+	}
+	return nil
+}
+
+func (s *BadgeService) ListBadges(ctx context.Context) ([]model.Badge, error) {
+	return s.repo.ListBadges(ctx)
+}
+
+// CreateBadge добавляет значок; уже выполнившие условие пользователи получат его
+// при следующем подходящем событии.
+func (s *BadgeService) CreateBadge(ctx context.Context, badge *model.Badge) error {
+	badge.ID = strings.ToLower(strings.TrimSpace(badge.ID))
+	badge.Name = strings.TrimSpace(badge.Name)
+	switch {
+	case !badgeIDPattern.MatchString(badge.ID):
+		return fmt.Errorf("%w: id must be 1-50 lowercase letters, digits or '_'", ErrInvalidBadge)
+	case badge.Name == "" || len(badge.Name) > 100:
+		return fmt.Errorf("%w: name is required", ErrInvalidBadge)
+	case !repository.SupportsBadgeRule(badge.Rule):
+		return fmt.Errorf("%w: unknown rule %q", ErrInvalidBadge, badge.Rule)
+	case badge.Threshold <= 0:
+		return fmt.Errorf("%w: threshold must be positive", ErrInvalidBadge)
+	}
+	return s.repo.CreateBadge(ctx, badge)
+}
+
+func (s *BadgeService) ListUserBadges(ctx context.Context, userID string) ([]model.UserBadge, error) {
+	return s.repo.ListUserBadges(ctx, userID)
+}
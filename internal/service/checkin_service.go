@@ -0,0 +1,148 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const defaultCheckinTimezone = "UTC"
+
+var (
+	ErrInvalidTimezone = errors.New("unknown timezone")
+	ErrFreezesDisabled = errors.New("streak freezes are disabled")
+)
+
+type CheckinService struct {
+	repo repository.CheckinRepository
+	cfg  config.CheckinConfig
+	bus  *events.Bus
+}
+
+func NewCheckinService(repo repository.CheckinRepository, cfg config.CheckinConfig, bus *events.Bus) (*CheckinService, error) {
+	if cfg.Timezone == "" {
+		cfg.Timezone = defaultCheckinTimezone
+	}
+	if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+		return nil, fmt.Errorf("load checkin timezone: %w", err)
+	}
+	return &CheckinService{repo: repo, cfg: cfg, bus: bus}, nil
+}
+
+// CheckIn отмечает пользователя за текущий день в его часовом поясе. timezone задаёт
+// пояс при первой отметке; потом он не меняется, пустой — пояс по умолчанию.
+func (s *CheckinService) CheckIn(ctx context.Context, userID, timezone string) (*model.CheckIn, error) {
+	timezone, _, err := s.resolveTimezone(ctx, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := func(timezone string) time.Time {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			loc = time.UTC
+		}
+		return localDate(now, loc)
+	}
+	checkin, err := s.repo.CheckIn(ctx, userID, timezone, today, s.cfg.Reward)
+	if err != nil {
+		return nil, err
+	}
+
+	if checkin.Reward > 0 {
+		s.bus.Publish(events.BalanceChanged, userID, checkin.Change)
+	}
+	s.bus.Publish(events.CheckedIn, userID, checkin)
+	return checkin, nil
+}
+
+// BuyFreeze покупает заморозку серии за cfg.FreezeCost очков.
+func (s *CheckinService) BuyFreeze(ctx context.Context, userID string) (*model.FreezePurchase, error) {
+	if s.cfg.FreezeCost <= 0 || s.cfg.MaxFreezes <= 0 {
+		return nil, ErrFreezesDisabled
+	}
+
+	timezone, _, err := s.resolveTimezone(ctx, userID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	purchase, err := s.repo.BuyFreeze(ctx, userID, timezone, s.cfg.FreezeCost, s.cfg.MaxFreezes)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.BalanceChanged, userID, purchase.Change)
+	return purchase, nil
+}
+
+// GetStreak возвращает состояние серии на текущий момент; nil — пользователь не отмечался.
+func (s *CheckinService) GetStreak(ctx context.Context, userID string) (*model.StreakStatus, error) {
+	streak, err := s.repo.GetStreak(ctx, userID)
+	if err != nil || streak == nil {
+		return nil, err
+	}
+
+	loc, err := time.LoadLocation(streak.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	today := localDate(time.Now(), loc)
+
+	status := &model.StreakStatus{
+		Current:  streak.Current,
+		Longest:  streak.Longest,
+		Freezes:  streak.Freezes,
+		Timezone: streak.Timezone,
+	}
+	if streak.LastCheckin == nil {
+		status.NextReward = s.cfg.Reward(1)
+		return status, nil
+	}
+
+	status.LastCheckin = streak.LastCheckin.Format(time.DateOnly)
+	missed := int(today.Sub(*streak.LastCheckin).Hours()/24) - 1
+	switch {
+	case missed < 0:
+		status.CheckedInToday = true
+		status.NextReward = s.cfg.Reward(streak.Current + 1)
+	case missed <= streak.Freezes:
+		status.NextReward = s.cfg.Reward(streak.Current + 1)
+	default:
+		status.Current = 0
+		status.NextReward = s.cfg.Reward(1)
+	}
+	return status, nil
+}
+
+func (s *CheckinService) resolveTimezone(ctx context.Context, userID, timezone string) (string, *time.Location, error) {
+	if timezone == "" {
+		streak, err := s.repo.GetStreak(ctx, userID)
+		if err != nil {
+			return "", nil, err
+		}
+		timezone = s.cfg.Timezone
+		if streak != nil {
+			timezone = streak.Timezone
+		}
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return "", nil, fmt.Errorf("%w: %s", ErrInvalidTimezone, timezone)
+	}
+	return timezone, loc, nil
+}
+
+// localDate возвращает календарную дату t в поясе loc как полночь UTC — так же,
+// как PostgreSQL отдаёт колонки DATE.
+func localDate(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%s) error = %v", name, err)
+	}
+	return loc
+}
+
+func TestLocalDate(t *testing.T) {
+	tokyo := mustLoad(t, "Asia/Tokyo")
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+
+	tests := []struct {
+		name string
+		t    time.Time
+		loc  *time.Location
+		want string
+	}{
+		{name: "utc midday", t: time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC), loc: time.UTC, want: "2024-03-10"},
+		{name: "already next day in tokyo", t: time.Date(2024, 3, 10, 15, 30, 0, 0, time.UTC), loc: tokyo, want: "2024-03-11"},
+		{name: "still previous day in los angeles", t: time.Date(2024, 3, 10, 5, 0, 0, 0, time.UTC), loc: losAngeles, want: "2024-03-09"},
+		{name: "last second of the local day", t: time.Date(2024, 3, 10, 14, 59, 59, 0, time.UTC), loc: tokyo, want: "2024-03-10"},
+		{name: "new year in tokyo", t: time.Date(2023, 12, 31, 15, 0, 0, 0, time.UTC), loc: tokyo, want: "2024-01-01"},
+		{name: "dst switch day", t: time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC), loc: losAngeles, want: "2024-03-10"},
+		{name: "input in another zone", t: time.Date(2024, 3, 11, 1, 0, 0, 0, tokyo), loc: time.UTC, want: "2024-03-10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := localDate(tt.t, tt.loc)
+			if got.Location() != time.UTC || got.Hour() != 0 || got.Minute() != 0 || got.Second() != 0 {
+				t.Fatalf("localDate() = %v, want UTC midnight", got)
+			}
+			if date := got.Format(time.DateOnly); date != tt.want {
+				t.Fatalf("localDate() = %s, want %s", date, tt.want)
+			}
+		})
+	}
+}
+
+// TestLocalDateDayDifference проверяет, что разница дат всегда кратна суткам,
+// в том числе через переход на летнее время: на ней строится счёт пропущенных дней.
+func TestLocalDateDayDifference(t *testing.T) {
+	losAngeles := mustLoad(t, "America/Los_Angeles")
+
+	before := localDate(time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC), losAngeles)
+	after := localDate(time.Date(2024, 3, 11, 20, 0, 0, 0, time.UTC), losAngeles)
+	if got := after.Sub(before); got != 48*time.Hour {
+		t.Fatalf("difference across DST = %v, want 48h", got)
+	}
+}
+
+func TestResolveTimezone(t *testing.T) {
+	s := &CheckinService{}
+
+	tests := []struct {
+		timezone string
+		wantErr  bool
+	}{
+		{timezone: "UTC"},
+		{timezone: "Europe/Moscow"},
+		{timezone: "Local", wantErr: true},
+		{timezone: "Mars/Olympus", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			got, loc, err := s.resolveTimezone(context.Background(), "user", tt.timezone)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTimezone) {
+					t.Fatalf("resolveTimezone(%s) error = %v, want ErrInvalidTimezone", tt.timezone, err)
+				}
+				return
+			}
+			if err != nil || got != tt.timezone || loc.String() != tt.timezone {
+				t.Fatalf("resolveTimezone(%s) = %s, %v, %v", tt.timezone, got, loc, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultPayoutBatch = 500
+
+var ErrInvalidCommunityGoal = errors.New("invalid community goal")
+
+type CommunityService struct {
+	repo repository.CommunityRepository
+	cfg  config.CommunityConfig
+	bus  *events.Bus
+
+	progressMu    sync.Mutex
+	progressReady bool
+	lastProgress  map[int64]int
+}
+
+func NewCommunityService(repo repository.CommunityRepository, cfg config.CommunityConfig, bus *events.Bus) *CommunityService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultPayoutBatch
+	}
+	return &CommunityService{repo: repo, cfg: cfg, bus: bus, lastProgress: make(map[int64]int)}
+}
+
+// CreateGoal заводит общую цель; без starts_at она начинается сразу.
+func (s *CommunityService) CreateGoal(ctx context.Context, goal *model.CommunityGoal) error {
+	goal.Title = strings.TrimSpace(goal.Title)
+	goal.Task = strings.TrimSpace(goal.Task)
+	if goal.StartsAt.IsZero() {
+		goal.StartsAt = time.Now()
+	}
+
+	switch {
+	case goal.Title == "" || len(goal.Title) > maxChallengeTitle:
+		return fmt.Errorf("%w: title must be 1-%d characters", ErrInvalidCommunityGoal, maxChallengeTitle)
+	case goal.Task == "":
+		return fmt.Errorf("%w: task is required", ErrInvalidCommunityGoal)
+	case goal.Goal <= 0:
+		return fmt.Errorf("%w: goal must be positive", ErrInvalidCommunityGoal)
+	case goal.RewardPoints <= 0:
+		return fmt.Errorf("%w: reward_points must be positive", ErrInvalidCommunityGoal)
+	case !goal.EndsAt.After(goal.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidCommunityGoal)
+	case !goal.EndsAt.After(time.Now()):
+		return fmt.Errorf("%w: ends_at must be in the future", ErrInvalidCommunityGoal)
+	}
+
+	// Колонки TIMESTAMP хранят локальное время сервера
+	goal.StartsAt = goal.StartsAt.Local()
+	goal.EndsAt = goal.EndsAt.Local()
+
+	if err := s.repo.CreateGoal(ctx, goal); err != nil {
+		return err
+	}
+	goal.Status = goalStatus(goal, time.Now())
+	return nil
+}
+
+func (s *CommunityService) GetGoal(ctx context.Context, id int64) (*model.CommunityGoal, error) {
+	goal, err := s.repo.GetGoal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	goal.Status = goalStatus(goal, time.Now())
+	return goal, nil
+}
+
+func (s *CommunityService) ListGoals(ctx context.Context, limit, offset int) (*model.CommunityGoalsPage, error) {
+	goals, err := s.repo.ListGoals(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list community goals: %w", err)
+	}
+
+	total, err := s.repo.CountGoals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count community goals: %w", err)
+	}
+
+	now := time.Now()
+	for i := range goals {
+		goals[i].Status = goalStatus(&goals[i], now)
+	}
+	return &model.CommunityGoalsPage{
+		Goals:  goals,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// PublishProgress отмечает достигнутые цели и рассылает всем прогресс идущих целей,
+// если он изменился с прошлой проверки.
+func (s *CommunityService) PublishProgress(ctx context.Context) error {
+	s.progressMu.Lock()
+	defer s.progressMu.Unlock()
+
+	if err := s.markReached(ctx); err != nil {
+		return err
+	}
+
+	running, err := s.repo.ListRunning(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Первый снимок после старта только запоминаем: клиенты получают прогресс через REST
+	publish := s.progressReady
+	current := make(map[int64]int, len(running))
+	for _, goal := range running {
+		current[goal.ID] = goal.Progress
+		if last, ok := s.lastProgress[goal.ID]; publish && (!ok || last != goal.Progress) {
+			s.bus.Publish(events.CommunityProgress, "", model.CommunityGoalProgress{
+				GoalID:   goal.ID,
+				Progress: goal.Progress,
+				Goal:     goal.Goal,
+			})
+		}
+	}
+	s.lastProgress, s.progressReady = current, true
+	return nil
+}
+
+// PayoutDue выплачивает награды достигнутых целей пачками по cfg.BatchSize участников;
+// безопасно запускать повторно. Сначала отмечает достигнутые цели, чтобы выплаты не зависели
+// от задачи прогресса; ошибка по одной цели не останавливает выплаты по остальным.
+func (s *CommunityService) PayoutDue(ctx context.Context) error {
+	if err := s.markReached(ctx); err != nil {
+		return err
+	}
+
+	ids, err := s.repo.GetPayableGoals(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get payable community goals: %w", err)
+	}
+
+	for _, id := range ids {
+		paid := 0
+		for {
+			changes, finished, err := s.repo.PayoutBatch(ctx, id, s.cfg.BatchSize)
+			if err != nil {
+				log.Printf("Failed to pay community goal %d: %v", id, err)
+				break
+			}
+			for _, change := range changes {
+				s.bus.Publish(events.BalanceChanged, change.UserID, change)
+			}
+			paid += len(changes)
+			if finished || len(changes) == 0 {
+				break
+			}
+		}
+		if paid > 0 {
+			log.Printf("Community goal %d: rewarded %d participants", id, paid)
+		}
+	}
+	return nil
+}
+
+// markReached отмечает достигнутые цели и рассылает community_goal_reached; каждая цель
+// отмечается один раз, поэтому вызывать его могут обе фоновые задачи.
+func (s *CommunityService) markReached(ctx context.Context) error {
+	reached, err := s.repo.MarkReached(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to mark community goals reached: %w", err)
+	}
+	for _, id := range reached {
+		log.Printf("Community goal %d reached", id)
+		goal, err := s.GetGoal(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load reached community goal %d: %v", id, err)
+			continue
+		}
+		s.bus.Publish(events.CommunityReached, "", goal)
+	}
+	return nil
+}
+
+func goalStatus(goal *model.CommunityGoal, now time.Time) model.CommunityGoalStatus {
+	switch {
+	case goal.RewardedAt != nil:
+		return model.CommunityGoalRewarded
+	case goal.ReachedAt != nil:
+		return model.CommunityGoalReached
+	case now.Before(goal.StartsAt):
+		return model.CommunityGoalUpcoming
+	case now.Before(goal.EndsAt):
+		return model.CommunityGoalActive
+	default:
+		return model.CommunityGoalFailed
+	}
+}
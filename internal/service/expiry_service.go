@@ -0,0 +1,74 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const defaultExpiryBatchSize = 100
+
+type ExpiryService struct {
+	repo repository.ExpiryRepository
+	cfg  config.ExpiryConfig
+	bus  *events.Bus
+}
+
+func NewExpiryService(repo repository.ExpiryRepository, cfg config.ExpiryConfig, bus *events.Bus) *ExpiryService {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultExpiryBatchSize
+	}
+	return &ExpiryService{repo: repo, cfg: cfg, bus: bus}
+}
+
+// ExpireDue сжигает очки, начисленные больше cfg.Months месяцев назад,
+// обрабатывая пользователей пачками по cfg.BatchSize. Ошибка по одному пользователю
+// не останавливает остальных: он будет обработан снова при следующем запуске.
+func (s *ExpiryService) ExpireDue(ctx context.Context) error {
+	if !s.cfg.Active() {
+		return nil
+	}
+
+	cutoff := time.Now().AddDate(0, -s.cfg.Months, 0)
+	after := ""
+	for {
+		ids, err := s.repo.GetUsersWithExpired(ctx, cutoff, after, s.cfg.BatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get users with expired points: %w", err)
+		}
+
+		for _, id := range ids {
+			after = id
+			change, err := s.repo.ExpireUserPoints(ctx, id, cutoff)
+			if err != nil {
+				log.Printf("Failed to expire points of user %s: %v", id, err)
+				continue
+			}
+			if change != nil {
+				log.Printf("Expired %d points of user %s", -change.Delta, id)
+				s.bus.Publish(events.BalanceChanged, id, *change)
+			}
+		}
+
+		if len(ids) < s.cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// ExpiringSoon возвращает очки пользователя, которые сгорят в ближайшие cfg.WarnBefore.
+func (s *ExpiryService) ExpiringSoon(ctx context.Context, userID string) ([]model.ExpiringPoints, error) {
+	if !s.cfg.Active() || s.cfg.WarnBefore <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	from := now.AddDate(0, -s.cfg.Months, 0)
+	until := now.Add(s.cfg.WarnBefore).AddDate(0, -s.cfg.Months, 0)
+	return s.repo.GetExpiring(ctx, userID, from, until, s.cfg.Months)
+}
@@ -0,0 +1,537 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/ranking"
+	"Test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidPeriod     = errors.New("period must be one of day, week, month, all")
+	ErrInvalidScope      = errors.New("scope must be one of global, friends, network, cohort")
+	ErrInvalidCohort     = errors.New("cohort is required for scope=cohort")
+	ErrInvalidVisibility = errors.New("visibility must be one of visible, excluded, shadow")
+	ErrInvalidCurrency   = errors.New("currency must be one of points, xp")
+)
+
+const (
+	maxCohortLength = 32
+
+	// indexBuffer — буфер подписки индекса рейтинга на шину событий
+	indexBuffer = 256
+
+	// indexSyncOverlap перекрывает окна синхронизации, чтобы не потерять изменения
+	// из транзакций, закоммиченных позже отметки xp_reached_at.
+	indexSyncOverlap = 5 * time.Second
+)
+
+type LeaderboardService struct {
+	userRepo  repository.UserRepository
+	location  *time.Location
+	weekStart time.Weekday
+
+	// index — рейтинг по опыту за всё время в памяти; nil, если выключен в конфиге
+	index     *ranking.Index
+	syncMu    sync.Mutex
+	watermark time.Time
+
+	bus      *events.Bus
+	topMu    sync.Mutex
+	topReady bool
+	lastTop  []model.LeaderboardEntry
+}
+
+func NewLeaderboardService(userRepo repository.UserRepository, cfg config.LeaderboardConfig, bus *events.Bus) (*LeaderboardService, error) {
+	location := time.UTC
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("load leaderboard timezone: %w", err)
+		}
+		location = loc
+	}
+
+	weekStart := time.Monday
+	if cfg.WeekStart != "" {
+		day, err := parseWeekday(cfg.WeekStart)
+		if err != nil {
+			return nil, err
+		}
+		weekStart = day
+	}
+
+	service := &LeaderboardService{
+		userRepo:  userRepo,
+		location:  location,
+		weekStart: weekStart,
+		bus:       bus,
+	}
+	if cfg.IndexSyncInterval > 0 {
+		service.index = ranking.New()
+	}
+	return service, nil
+}
+
+// GetLeaderboard возвращает страницу рейтинга в валюте currency за период и, если известен
+// вызывающий, его позицию с around соседями сверху и снизу. Scope сужает рейтинг до друзей,
+// реферальной сети или когорты вызывающего.
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, callerID string, currency model.Currency, period model.LeaderboardPeriod, scope model.LeaderboardScope, cohort string, limit, offset, around int) (*model.LeaderboardPage, error) {
+	currency, err := validateCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	since, err := s.periodStart(period, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	scope, cohort, err = validateScope(scope, cohort, callerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// В индексе только опыт видимых пользователей: скрытый вызывающий получает рейтинг из базы
+	if currency == model.CurrencyXP && since == nil && scope == model.ScopeGlobal &&
+		s.index != nil && s.indexed(callerID) {
+		return s.indexLeaderboard(callerID, limit, offset, around), nil
+	}
+
+	q := model.LeaderboardQuery{
+		Currency:    currency,
+		Since:       since,
+		Scope:       scope,
+		ScopeUserID: callerID,
+		Cohort:      cohort,
+		ViewerID:    callerID,
+		Limit:       limit,
+		Offset:      offset,
+	}
+	entries, err := s.userRepo.GetLeaderboard(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	total, err := s.userRepo.CountRanked(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count ranked users: %w", err)
+	}
+
+	page := &model.LeaderboardPage{
+		Currency: currency,
+		Period:   period,
+		Scope:    scope,
+		Cohort:   cohort,
+		From:     since,
+		Entries:  entries,
+		Total:    total,
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	if callerID == "" {
+		return page, nil
+	}
+
+	position, points, err := s.userRepo.GetUserRank(ctx, callerID, q)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return page, nil
+		}
+		return nil, fmt.Errorf("failed to get user rank: %w", err)
+	}
+
+	start := position - 1 - around
+	if start < 0 {
+		start = 0
+	}
+	q.Limit, q.Offset = position-start+around, start
+	neighbors, err := s.userRepo.GetLeaderboard(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard neighbors: %w", err)
+	}
+
+	page.Me = &model.LeaderboardMe{
+		Position:  position,
+		Points:    points,
+		Neighbors: neighbors,
+	}
+	return page, nil
+}
+
+func (s *LeaderboardService) indexed(callerID string) bool {
+	if callerID == "" {
+		return true
+	}
+	_, ok := s.index.Get(callerID)
+	return ok
+}
+
+func (s *LeaderboardService) indexLeaderboard(callerID string, limit, offset, around int) *model.LeaderboardPage {
+	page := &model.LeaderboardPage{
+		Currency: model.CurrencyXP,
+		Period:   model.PeriodAll,
+		Scope:    model.ScopeGlobal,
+		Entries:  toLeaderboardEntries(s.index.Range(offset, limit), offset),
+		Total:    s.index.Len(),
+		Limit:    limit,
+		Offset:   offset,
+	}
+
+	position, entry, ok := s.index.Rank(callerID)
+	if !ok {
+		return page
+	}
+
+	start := position - 1 - around
+	if start < 0 {
+		start = 0
+	}
+	page.Me = &model.LeaderboardMe{
+		Position:  position,
+		Points:    entry.Score,
+		Neighbors: toLeaderboardEntries(s.index.Range(start, position-start+around), start),
+	}
+	return page
+}
+
+func validateScope(scope model.LeaderboardScope, cohort, callerID string) (model.LeaderboardScope, string, error) {
+	switch scope {
+	case "", model.ScopeGlobal:
+		return model.ScopeGlobal, "", nil
+	case model.ScopeFriends, model.ScopeNetwork:
+		cohort = ""
+	case model.ScopeCohort:
+		if cohort == "" || len(cohort) > maxCohortLength {
+			return "", "", ErrInvalidCohort
+		}
+	default:
+		return "", "", ErrInvalidScope
+	}
+
+	if callerID == "" {
+		return "", "", ErrInvalidScope
+	}
+	return scope, cohort, nil
+}
+
+func validateCurrency(currency model.Currency) (model.Currency, error) {
+	switch currency {
+	case "":
+		return model.CurrencyXP, nil
+	case model.CurrencyPoints, model.CurrencyXP:
+		return currency, nil
+	default:
+		return "", ErrInvalidCurrency
+	}
+}
+
+func toLeaderboardEntries(entries []ranking.Entry, offset int) []model.LeaderboardEntry {
+	result := make([]model.LeaderboardEntry, 0, len(entries))
+	for i, e := range entries {
+		result = append(result, model.LeaderboardEntry{
+			UserID:   e.UserID,
+			Name:     e.Name,
+			Points:   e.Score,
+			Position: offset + i + 1,
+		})
+	}
+	return result
+}
+
+func toRankingEntry(score model.UserScore) ranking.Entry {
+	return ranking.Entry{
+		UserID:    score.UserID,
+		Name:      score.Name,
+		Score:     score.XP,
+		ReachedAt: score.ReachedAt,
+	}
+}
+
+// WarmIndex загружает в индекс всех пользователей; вызывается при старте.
+func (s *LeaderboardService) WarmIndex(ctx context.Context) error {
+	if s.index == nil {
+		return nil
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	scores, err := s.userRepo.GetScores(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to load scores: %w", err)
+	}
+
+	entries := make([]ranking.Entry, 0, len(scores))
+	for _, score := range scores {
+		entries = append(entries, toRankingEntry(score))
+		if score.ReachedAt.After(s.watermark) {
+			s.watermark = score.ReachedAt
+		}
+	}
+	s.index.Replace(entries)
+
+	log.Printf("Ranking index warmed with %d users", len(entries))
+	return nil
+}
+
+// Run обновляет индекс по событиям изменения опыта до отмены ctx. При отставании от шины
+// подписка восстанавливается, а пропущенные изменения подтягивает SyncIndex.
+func (s *LeaderboardService) Run(ctx context.Context) {
+	if s.index == nil {
+		return
+	}
+	for {
+		sub := s.bus.Subscribe(xpChanged, indexBuffer)
+		s.consume(ctx, sub)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Ranking index fell behind the event bus, resubscribing")
+		if err := s.SyncIndex(ctx); err != nil {
+			log.Printf("Ranking index sync failed: %v", err)
+		}
+	}
+}
+
+func xpChanged(event events.Event) bool {
+	if event.Type != events.BalanceChanged {
+		return false
+	}
+	switch change := event.Data.(type) {
+	case model.BalanceChange:
+		return change.Currency == model.CurrencyXP
+	case *model.BalanceChange:
+		return change != nil && change.Currency == model.CurrencyXP
+	}
+	return false
+}
+
+func (s *LeaderboardService) consume(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if err := s.refreshIndex(ctx, event.UserID); err != nil {
+				log.Printf("Ranking index update for user %s failed: %v", event.UserID, err)
+			}
+		}
+	}
+}
+
+// refreshIndex перечитывает опыт пользователя из базы: события могут прийти не по порядку,
+// а в базе всегда итоговый баланс и момент его достижения.
+func (s *LeaderboardService) refreshIndex(ctx context.Context, userID string) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	score, err := s.userRepo.GetScore(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.index.Remove(userID)
+			return nil
+		}
+		return fmt.Errorf("failed to load score: %w", err)
+	}
+	s.index.Upsert(toRankingEntry(*score))
+	return nil
+}
+
+// SyncIndex подтягивает в индекс опыт, изменившийся с прошлой синхронизации. Основной путь
+// обновления — события шины (Run); опрос по users.xp_reached_at чинит индекс после пропущенных
+// событий и подхватывает начисления на других экземплярах сервера.
+func (s *LeaderboardService) SyncIndex(ctx context.Context) error {
+	if s.index == nil {
+		return nil
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	since := s.watermark.Add(-indexSyncOverlap)
+	scores, err := s.userRepo.GetScores(ctx, &since)
+	if err != nil {
+		return fmt.Errorf("failed to load changed scores: %w", err)
+	}
+
+	for _, score := range scores {
+		s.index.Upsert(toRankingEntry(score))
+		if score.ReachedAt.After(s.watermark) {
+			s.watermark = score.ReachedAt
+		}
+	}
+	return nil
+}
+
+// CheckIndex сверяет индекс с PostgreSQL и исправляет найденные расхождения.
+func (s *LeaderboardService) CheckIndex(ctx context.Context) (*model.RankingCheck, error) {
+	if s.index == nil {
+		return nil, errors.New("ranking index is disabled")
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	scores, err := s.userRepo.GetScores(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scores: %w", err)
+	}
+
+	check := &model.RankingCheck{
+		CheckedAt: time.Now(),
+		Users:     len(scores),
+		Indexed:   s.index.Len(),
+	}
+
+	seen := make(map[string]struct{}, len(scores))
+	for _, score := range scores {
+		seen[score.UserID] = struct{}{}
+
+		indexed, ok := s.index.Get(score.UserID)
+		switch {
+		case !ok:
+			check.Missing++
+		case indexed.Score != score.XP || !indexed.ReachedAt.Equal(score.ReachedAt):
+			check.Stale++
+		default:
+			continue
+		}
+		s.index.Upsert(toRankingEntry(score))
+	}
+
+	for _, indexed := range s.index.All() {
+		if _, ok := seen[indexed.UserID]; !ok {
+			check.Extra++
+			s.index.Remove(indexed.UserID)
+		}
+	}
+
+	check.Consistent = check.Missing == 0 && check.Stale == 0 && check.Extra == 0
+	if !check.Consistent {
+		log.Printf("Ranking index repaired: %d missing, %d stale, %d extra", check.Missing, check.Stale, check.Extra)
+	}
+	return check, nil
+}
+
+// SetVisibility исключает пользователя из рейтингов, накладывает теневой бан или возвращает его.
+// Другие экземпляры сервера подхватят изменение при ближайшей сверке индекса.
+func (s *LeaderboardService) SetVisibility(ctx context.Context, userID string, visibility model.LeaderboardVisibility) error {
+	switch visibility {
+	case model.VisibilityVisible, model.VisibilityExcluded, model.VisibilityShadow:
+	default:
+		return ErrInvalidVisibility
+	}
+
+	// Блокируем синхронизацию, чтобы она не вернула в индекс только что скрытого пользователя
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	score, err := s.userRepo.SetLeaderboardVisibility(ctx, userID, visibility)
+	if err != nil {
+		return err
+	}
+
+	if s.index != nil {
+		if visibility == model.VisibilityVisible {
+			s.index.Upsert(toRankingEntry(*score))
+		} else {
+			s.index.Remove(userID)
+		}
+	}
+	return nil
+}
+
+func (s *LeaderboardService) ListHidden(ctx context.Context, limit, offset int) (*model.HiddenUsersPage, error) {
+	users, err := s.userRepo.ListHidden(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list hidden users: %w", err)
+	}
+
+	total, err := s.userRepo.CountHidden(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count hidden users: %w", err)
+	}
+
+	return &model.HiddenUsersPage{
+		Users:  users,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// PublishTopChanges рассылает всем подписчикам топ-limit рейтинга по опыту за всё время,
+// если он изменился с прошлой проверки.
+func (s *LeaderboardService) PublishTopChanges(ctx context.Context, limit int) error {
+	s.topMu.Lock()
+	defer s.topMu.Unlock()
+
+	page, err := s.GetLeaderboard(ctx, "", model.CurrencyXP, model.PeriodAll, model.ScopeGlobal, "", limit, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	if s.topReady && slices.Equal(page.Entries, s.lastTop) {
+		return nil
+	}
+
+	// Первый снимок после старта только запоминаем: клиенты получают топ через REST
+	publish := s.topReady
+	s.lastTop, s.topReady = page.Entries, true
+	if publish {
+		s.bus.Publish(events.LeaderboardChanged, "", page.Entries)
+	}
+	return nil
+}
+
+// periodStart возвращает начало текущего периода в настроенном часовом поясе
+// или nil для рейтинга за всё время.
+func (s *LeaderboardService) periodStart(period model.LeaderboardPeriod, now time.Time) (*time.Time, error) {
+	local := now.In(s.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, s.location)
+
+	var start time.Time
+	switch period {
+	case model.PeriodAll, "":
+		return nil, nil
+	case model.PeriodDay:
+		start = midnight
+	case model.PeriodWeek:
+		daysSinceStart := (int(local.Weekday()) - int(s.weekStart) + 7) % 7
+		start = midnight.AddDate(0, 0, -daysSinceStart)
+	case model.PeriodMonth:
+		start = time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, s.location)
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	// Колонки TIMESTAMP хранят локальное время сервера, поэтому сравниваем в нём же
+	start = start.Local()
+	return &start, nil
+}
+
+func parseWeekday(name string) (time.Weekday, error) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), name) {
+			return day, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown week start %q", name)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxPromoBatchSize = 10000
+	promoCodeLength   = 10
+	// Без похожих символов (0/O, 1/I/L), чтобы коды было легко вводить с листовки
+	promoAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	// Повторная генерация партии при совпадении с уже существующим кодом
+	promoGenerateAttempts = 3
+)
+
+var (
+	ErrInvalidPromoBatch = errors.New("invalid promo batch")
+	ErrInvalidPromoCode  = errors.New("promo code must be 4-32 letters, digits, '-' or '_'")
+
+	promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{4,32}$`)
+)
+
+type PromoService struct {
+	repo repository.PromoRepository
+	bus  *events.Bus
+}
+
+func NewPromoService(repo repository.PromoRepository, bus *events.Bus) *PromoService {
+	return &PromoService{repo: repo, bus: bus}
+}
+
+// CreateBatch создаёт партию: с заданным code — один код, иначе count сгенерированных
+// кодов с необязательным префиксом.
+func (s *PromoService) CreateBatch(ctx context.Context, batch *model.PromoBatch, code, prefix string, count int) error {
+	if err := validatePromoBatch(batch); err != nil {
+		return err
+	}
+
+	if code != "" {
+		code = normalizePromoCode(code)
+		if !promoCodePattern.MatchString(code) {
+			return ErrInvalidPromoCode
+		}
+		return s.repo.CreateBatch(ctx, batch, []string{code})
+	}
+
+	prefix = normalizePromoCode(prefix)
+	if count <= 0 || count > maxPromoBatchSize {
+		return fmt.Errorf("%w: count must be between 1 and %d", ErrInvalidPromoBatch, maxPromoBatchSize)
+	}
+	if prefix != "" && !promoCodePattern.MatchString(prefix+strings.Repeat("A", promoCodeLength)) {
+		return ErrInvalidPromoCode
+	}
+
+	for attempt := 1; ; attempt++ {
+		codes, err := generatePromoCodes(prefix, count)
+		if err != nil {
+			return err
+		}
+
+		err = s.repo.CreateBatch(ctx, batch, codes)
+		if !errors.Is(err, repository.ErrPromoCodeExists) || attempt == promoGenerateAttempts {
+			return err
+		}
+	}
+}
+
+func validatePromoBatch(batch *model.PromoBatch) error {
+	batch.Name = strings.TrimSpace(batch.Name)
+	switch {
+	case batch.Name == "" || len(batch.Name) > 100:
+		return fmt.Errorf("%w: name is required", ErrInvalidPromoBatch)
+	case batch.Points <= 0:
+		return fmt.Errorf("%w: points must be positive", ErrInvalidPromoBatch)
+	case batch.MaxUses != nil && *batch.MaxUses <= 0:
+		return fmt.Errorf("%w: max_uses must be positive", ErrInvalidPromoBatch)
+	case batch.MaxUsesPerUser < 0:
+		return fmt.Errorf("%w: max_uses_per_user must be positive", ErrInvalidPromoBatch)
+	case batch.ValidFrom != nil && batch.ValidUntil != nil && !batch.ValidUntil.After(*batch.ValidFrom):
+		return fmt.Errorf("%w: valid_until must be after valid_from", ErrInvalidPromoBatch)
+	}
+
+	if batch.MaxUsesPerUser == 0 {
+		batch.MaxUsesPerUser = 1
+	}
+	// Колонки TIMESTAMP хранят локальное время сервера
+	if batch.ValidFrom != nil {
+		local := batch.ValidFrom.Local()
+		batch.ValidFrom = &local
+	}
+	if batch.ValidUntil != nil {
+		local := batch.ValidUntil.Local()
+		batch.ValidUntil = &local
+	}
+	return nil
+}
+
+func generatePromoCodes(prefix string, count int) ([]string, error) {
+	seen := make(map[string]struct{}, count)
+	codes := make([]string, 0, count)
+	alphabetSize := big.NewInt(int64(len(promoAlphabet)))
+
+	for len(codes) < count {
+		var b strings.Builder
+		b.WriteString(prefix)
+		for i := 0; i < promoCodeLength; i++ {
+			n, err := rand.Int(rand.Reader, alphabetSize)
+			if err != nil {
+				return nil, fmt.Errorf("generate promo code: %w", err)
+			}
+			b.WriteByte(promoAlphabet[n.Int64()])
+		}
+
+		code := b.String()
+		if _, ok := seen[code]; ok {
+			continue
+		}
+		seen[code] = struct{}{}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func (s *PromoService) ListBatches(ctx context.Context, limit, offset int) ([]model.PromoBatch, int, error) {
+	batches, err := s.repo.ListBatches(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list promo batches: %w", err)
+	}
+
+	total, err := s.repo.CountBatches(ctx)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count promo batches: %w", err)
+	}
+	return batches, total, nil
+}
+
+func (s *PromoService) ListCodes(ctx context.Context, batchID int64) ([]model.PromoCode, error) {
+	return s.repo.ListCodes(ctx, batchID)
+}
+
+func (s *PromoService) Redeem(ctx context.Context, userID, code string) (*model.PromoRedemption, error) {
+	code = normalizePromoCode(code)
+	if !promoCodePattern.MatchString(code) {
+		return nil, repository.ErrPromoNotFound
+	}
+
+	change, err := s.repo.Redeem(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.BalanceChanged, userID, *change)
+	return &model.PromoRedemption{Code: code, Points: change.Delta, Balance: change.Balance}, nil
+}
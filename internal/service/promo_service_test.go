@@ -0,0 +1,69 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizePromoCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "spring-2024", want: "SPRING-2024"},
+		{code: "  Welcome_10 ", want: "WELCOME_10"},
+		{code: "ABCD", want: "ABCD"},
+		{code: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := normalizePromoCode(tt.code); got != tt.want {
+				t.Fatalf("normalizePromoCode(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeneratePromoCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		count  int
+	}{
+		{name: "without prefix", prefix: "", count: 50},
+		{name: "with prefix", prefix: "SPRING-", count: 50},
+		{name: "single code", prefix: "X", count: 1},
+		{name: "large batch", prefix: "", count: 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codes, err := generatePromoCodes(tt.prefix, tt.count)
+			if err != nil {
+				t.Fatalf("generatePromoCodes() error = %v", err)
+			}
+			if len(codes) != tt.count {
+				t.Fatalf("generatePromoCodes() returned %d codes, want %d", len(codes), tt.count)
+			}
+
+			seen := make(map[string]struct{}, len(codes))
+			for _, code := range codes {
+				suffix, ok := strings.CutPrefix(code, tt.prefix)
+				if !ok || len(suffix) != promoCodeLength {
+					t.Fatalf("code %q does not match prefix %q and length %d", code, tt.prefix, promoCodeLength)
+				}
+				if i := strings.IndexFunc(suffix, func(r rune) bool { return !strings.ContainsRune(promoAlphabet, r) }); i >= 0 {
+					t.Fatalf("code %q has character %q outside the alphabet", code, suffix[i])
+				}
+				if !promoCodePattern.MatchString(code) {
+					t.Fatalf("code %q is rejected by the promo code pattern", code)
+				}
+				if _, ok := seen[code]; ok {
+					t.Fatalf("code %q generated twice", code)
+				}
+				seen[code] = struct{}{}
+			}
+		})
+	}
+}
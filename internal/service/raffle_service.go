@@ -0,0 +1,175 @@
+package service
+
+import (
+	"Test/internal/events"
+	"Test/internal/fairness"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	maxRaffleTitle        = 100
+	maxRafflePrize        = 200
+	maxRaffleWinners      = 100
+	maxTicketsPerPurchase = 100
+)
+
+var (
+	ErrInvalidRaffle       = errors.New("invalid raffle")
+	ErrInvalidTicketAmount = fmt.Errorf("quantity must be between 1 and %d", maxTicketsPerPurchase)
+	ErrInvalidRaffleStatus = errors.New("status must be one of open, drawn, cancelled")
+)
+
+type RaffleService struct {
+	repo repository.RaffleRepository
+	bus  *events.Bus
+}
+
+func NewRaffleService(repo repository.RaffleRepository, bus *events.Bus) *RaffleService {
+	return &RaffleService{repo: repo, bus: bus}
+}
+
+// CreateRaffle заводит розыгрыш и фиксирует серверный сид: до розыгрыша публикуется только его хеш.
+func (s *RaffleService) CreateRaffle(ctx context.Context, raffle *model.Raffle) error {
+	if err := validateRaffle(raffle); err != nil {
+		return err
+	}
+
+	// Колонки TIMESTAMP хранят локальное время сервера
+	raffle.DrawAt = raffle.DrawAt.Local()
+
+	serverSeed, err := fairness.NewSeed(serverSeedBytes)
+	if err != nil {
+		return err
+	}
+	raffle.ServerSeedHash = fairness.Hash(serverSeed)
+
+	if err := s.repo.CreateRaffle(ctx, raffle, serverSeed); err != nil {
+		return fmt.Errorf("failed to create raffle: %w", err)
+	}
+	return nil
+}
+
+func (s *RaffleService) GetRaffle(ctx context.Context, id int64) (*model.Raffle, error) {
+	return s.repo.GetRaffle(ctx, id)
+}
+
+func (s *RaffleService) ListRaffles(ctx context.Context, status model.RaffleStatus, limit, offset int) (*model.RafflesPage, error) {
+	switch status {
+	case "", model.RaffleOpen, model.RaffleDrawn, model.RaffleCancelled:
+	default:
+		return nil, ErrInvalidRaffleStatus
+	}
+
+	raffles, err := s.repo.ListRaffles(ctx, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list raffles: %w", err)
+	}
+
+	total, err := s.repo.CountRaffles(ctx, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count raffles: %w", err)
+	}
+
+	return &model.RafflesPage{
+		Raffles: raffles,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// ListEntries возвращает участников с диапазонами их билетов — по ним проверяется розыгрыш.
+func (s *RaffleService) ListEntries(ctx context.Context, raffleID int64) ([]model.RaffleEntry, error) {
+	if _, err := s.repo.GetRaffle(ctx, raffleID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListEntries(ctx, raffleID)
+}
+
+func (s *RaffleService) BuyTickets(ctx context.Context, raffleID int64, userID string, quantity int) (*model.RaffleTicketPurchase, error) {
+	if quantity <= 0 || quantity > maxTicketsPerPurchase {
+		return nil, ErrInvalidTicketAmount
+	}
+
+	purchase, err := s.repo.BuyTickets(ctx, raffleID, userID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.BalanceChanged, userID, *purchase.Change)
+	return purchase, nil
+}
+
+// DrawDue проводит все розыгрыши, время которых наступило; безопасно запускать повторно.
+// Ошибка по одному розыгрышу не останавливает остальные.
+func (s *RaffleService) DrawDue(ctx context.Context) error {
+	ids, err := s.repo.GetDueRaffles(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get due raffles: %w", err)
+	}
+
+	for _, id := range ids {
+		changes, done, err := s.repo.DrawRaffle(ctx, id)
+		if err != nil {
+			log.Printf("Failed to draw raffle %d: %v", id, err)
+			continue
+		}
+		if !done {
+			continue
+		}
+
+		log.Printf("Raffle %d drawn", id)
+		for _, change := range changes {
+			s.bus.Publish(events.BalanceChanged, change.UserID, change)
+		}
+		raffle, err := s.repo.GetRaffle(ctx, id)
+		if err != nil {
+			log.Printf("Failed to load drawn raffle %d: %v", id, err)
+			continue
+		}
+		s.bus.Publish(events.RaffleDrawn, "", raffle)
+	}
+	return nil
+}
+
+// CancelRaffle отменяет розыгрыш и возвращает участникам потраченные на билеты очки.
+func (s *RaffleService) CancelRaffle(ctx context.Context, raffleID int64) (*model.Raffle, error) {
+	changes, err := s.repo.CancelRaffle(ctx, raffleID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		s.bus.Publish(events.BalanceChanged, change.UserID, change)
+	}
+	return s.repo.GetRaffle(ctx, raffleID)
+}
+
+func validateRaffle(raffle *model.Raffle) error {
+	raffle.Title = strings.TrimSpace(raffle.Title)
+	raffle.Prize = strings.TrimSpace(raffle.Prize)
+	switch {
+	case raffle.Title == "" || len(raffle.Title) > maxRaffleTitle:
+		return fmt.Errorf("%w: title must be 1-%d characters", ErrInvalidRaffle, maxRaffleTitle)
+	case raffle.Prize == "" || len(raffle.Prize) > maxRafflePrize:
+		return fmt.Errorf("%w: prize must be 1-%d characters", ErrInvalidRaffle, maxRafflePrize)
+	case raffle.PrizePoints < 0:
+		return fmt.Errorf("%w: prize_points must not be negative", ErrInvalidRaffle)
+	case raffle.TicketPrice <= 0:
+		return fmt.Errorf("%w: ticket_price must be positive", ErrInvalidRaffle)
+	case raffle.MaxTicketsPerUser < 0:
+		return fmt.Errorf("%w: max_tickets_per_user must not be negative", ErrInvalidRaffle)
+	case raffle.Winners <= 0 || raffle.Winners > maxRaffleWinners:
+		return fmt.Errorf("%w: winners must be between 1 and %d", ErrInvalidRaffle, maxRaffleWinners)
+	case !raffle.DrawAt.After(time.Now()):
+		return fmt.Errorf("%w: draw_at must be in the future", ErrInvalidRaffle)
+	}
+	return nil
+}
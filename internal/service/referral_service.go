@@ -0,0 +1,286 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/fraud"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+)
+
+type ReferralService struct {
+	repo     repository.ReferralRepository
+	cfg      config.ReferralConfig
+	fraudCfg config.FraudConfig
+	bus      *events.Bus
+}
+
+func NewReferralService(repo repository.ReferralRepository, cfg config.ReferralConfig, fraudCfg config.FraudConfig, bus *events.Bus) *ReferralService {
+	return &ReferralService{repo: repo, cfg: cfg, fraudCfg: fraudCfg, bus: bus}
+}
+
+// SetReferrer привязывает реферера, но награда остаётся в статусе pending
+// до тех пор, пока реферал не выполнит условия квалификации.
+// Подозрительные пары уходят на ручную проверку (статус review).
+func (s *ReferralService) SetReferrer(ctx context.Context, userID, referrerID string) ([]string, error) {
+	if userID == referrerID {
+		return nil, repository.ErrSelfReferral
+	}
+
+	flags, err := s.checkFraud(ctx, userID, referrerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateReferral(ctx, userID, referrerID, s.cfg.RewardPoints, flags); err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.ReferralJoined, referrerID, map[string]string{"referee_id": userID})
+	return flags, nil
+}
+
+func (s *ReferralService) checkFraud(ctx context.Context, userID, referrerID string) ([]string, error) {
+	referee, err := s.repo.GetRegistrationInfo(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referee registration: %w", err)
+	}
+
+	referrer, err := s.repo.GetRegistrationInfo(ctx, referrerID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, repository.ErrReferrerNotFound
+		}
+		return nil, fmt.Errorf("failed to get referrer registration: %w", err)
+	}
+
+	duplicates, err := s.repo.CountDuplicateEmails(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check duplicate emails: %w", err)
+	}
+
+	recent, err := s.repo.CountReferralsSince(ctx, referrerID, time.Now().Add(-s.fraudCfg.BurstWindow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check referral burst: %w", err)
+	}
+
+	return fraud.Check(fraud.Signals{
+		Referee:         *referee,
+		Referrer:        *referrer,
+		DuplicateEmails: duplicates,
+		RecentReferrals: recent,
+	}, s.fraudCfg), nil
+}
+
+// EvaluatePending выплачивает награды по квалифицированным рефералам
+// и аннулирует те, что не уложились в отведённый срок. Ошибка по одному рефералу
+// не останавливает остальных: он будет проверен снова при следующем запуске.
+func (s *ReferralService) EvaluatePending(ctx context.Context) error {
+	pending, err := s.repo.GetPendingReferrals(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get pending referrals: %w", err)
+	}
+
+	now := time.Now()
+	var released, voided int
+	for _, p := range pending {
+		switch {
+		case s.qualified(p):
+			change, err := s.repo.ReleaseReward(ctx, p.RefereeID)
+			if err != nil {
+				log.Printf("Failed to release referral reward for %s: %v", p.RefereeID, err)
+				continue
+			}
+			if change != nil {
+				s.bus.Publish(events.BalanceChanged, change.UserID, *change)
+				s.bus.Publish(events.ReferralPaid, change.UserID, map[string]string{"referee_id": p.RefereeID})
+				released++
+			}
+		case s.cfg.QualifyWithin > 0 && now.Sub(p.Date) > s.cfg.QualifyWithin:
+			if err := s.repo.VoidReward(ctx, p.RefereeID, "qualification window expired"); err != nil {
+				log.Printf("Failed to void referral reward for %s: %v", p.RefereeID, err)
+				continue
+			}
+			voided++
+		}
+	}
+
+	if released > 0 || voided > 0 {
+		log.Printf("Referral rewards evaluated: %d released, %d voided", released, voided)
+	}
+	return nil
+}
+
+func (s *ReferralService) qualified(p model.PendingReferral) bool {
+	if s.cfg.RequireVerifiedEmail && p.RefereeVerifiedAt == nil {
+		return false
+	}
+	return p.CompletedTasks >= s.cfg.MinCompletedTasks && p.ActiveDays >= s.cfg.MinDaysActive
+}
+
+// GetReferralTree строит дерево приглашённых пользователя с разбивкой по уровням.
+func (s *ReferralService) GetReferralTree(ctx context.Context, userID string) (*model.ReferralTree, error) {
+	depth := s.cfg.Depth()
+
+	nodes, err := s.repo.GetDownline(ctx, userID, depth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get downline: %w", err)
+	}
+
+	commissions, err := s.repo.GetCommissionsByLevel(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commissions: %w", err)
+	}
+
+	tree := &model.ReferralTree{
+		UserID:   userID,
+		MaxDepth: depth,
+		Downline: []*model.ReferralNode{},
+	}
+
+	counts := make(map[int]int)
+	byID := make(map[string]*model.ReferralNode, len(nodes))
+	for i := range nodes {
+		node := &nodes[i]
+		byID[node.UserID] = node
+		counts[node.Level]++
+
+		if node.Level == 1 {
+			tree.Downline = append(tree.Downline, node)
+		} else if parent, ok := byID[node.ReferrerID]; ok {
+			parent.Referrals = append(parent.Referrals, node)
+		}
+	}
+
+	for level := 1; level <= depth; level++ {
+		if counts[level] == 0 && commissions[level] == 0 {
+			continue
+		}
+		tree.Levels = append(tree.Levels, model.ReferralLevelStats{
+			Level:            level,
+			Count:            counts[level],
+			CommissionPoints: commissions[level],
+		})
+		tree.TotalCommission += commissions[level]
+	}
+
+	return tree, nil
+}
+
+func (s *ReferralService) ListInvited(ctx context.Context, userID string, limit, offset int) (*model.InvitedUsersPage, error) {
+	items, err := s.repo.ListInvited(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invited users: %w", err)
+	}
+
+	total, err := s.repo.CountInvited(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count invited users: %w", err)
+	}
+
+	return &model.InvitedUsersPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+func (s *ReferralService) ListFlagged(ctx context.Context, limit, offset int) (*model.FlaggedReferralsPage, error) {
+	items, err := s.repo.ListFlagged(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list flagged referrals: %w", err)
+	}
+
+	total, err := s.repo.CountFlagged(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count flagged referrals: %w", err)
+	}
+
+	return &model.FlaggedReferralsPage{
+		Items:  items,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+func (s *ReferralService) ResolveFlagged(ctx context.Context, refereeID string, approve bool, note string) error {
+	return s.repo.ResolveFlagged(ctx, refereeID, approve, note)
+}
+
+func (s *ReferralService) RecordClick(ctx context.Context, referrerID, ip, userAgent string) error {
+	return s.repo.RecordClick(ctx, referrerID, ip, userAgent)
+}
+
+// InviteRedirectURL возвращает адрес, на который отправляется перешедший по ссылке пользователь.
+func (s *ReferralService) InviteRedirectURL(referrerID string) string {
+	target, err := url.Parse(s.cfg.InviteRedirectURL)
+	if err != nil || s.cfg.InviteRedirectURL == "" {
+		target = &url.URL{Path: "/"}
+	}
+
+	query := target.Query()
+	query.Set("ref", referrerID)
+	target.RawQuery = query.Encode()
+	return target.String()
+}
+
+func (s *ReferralService) GetStatsReport(ctx context.Context, from, to time.Time, limit, offset int) (*model.ReferralStatsReport, error) {
+	totals, err := s.repo.GetReferralTotals(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral totals: %w", err)
+	}
+
+	referrers, err := s.repo.GetReferralStats(ctx, "", from, to, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+
+	withRates(totals)
+	for i := range referrers {
+		withRates(&referrers[i])
+	}
+
+	return &model.ReferralStatsReport{
+		From:      from,
+		To:        to,
+		Totals:    *totals,
+		Referrers: referrers,
+	}, nil
+}
+
+func (s *ReferralService) GetUserStats(ctx context.Context, userID string, from, to time.Time) (*model.ReferralStatsReport, error) {
+	stats, err := s.repo.GetReferralStats(ctx, userID, from, to, 1, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referral stats: %w", err)
+	}
+
+	totals := model.ReferralStats{ReferrerID: userID}
+	if len(stats) > 0 {
+		totals = stats[0]
+	}
+	withRates(&totals)
+
+	return &model.ReferralStatsReport{
+		From:   from,
+		To:     to,
+		Totals: totals,
+	}, nil
+}
+
+func withRates(st *model.ReferralStats) {
+	if st.Clicks > 0 {
+		st.SignupRate = float64(st.Signups) / float64(st.Clicks)
+	}
+	if st.Signups > 0 {
+		st.QualificationRate = float64(st.Qualified) / float64(st.Signups)
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidReward      = errors.New("reward must have a name, a positive cost, non-negative stock and a positive per-user limit")
+	ErrInvalidOrderStatus = errors.New("status must be one of pending, fulfilled, cancelled")
+)
+
+type RewardService struct {
+	repo repository.RewardRepository
+	bus  *events.Bus
+}
+
+func NewRewardService(repo repository.RewardRepository, bus *events.Bus) *RewardService {
+	return &RewardService{repo: repo, bus: bus}
+}
+
+func (s *RewardService) ListCatalog(ctx context.Context) ([]model.Reward, error) {
+	return s.repo.ListRewards(ctx, true)
+}
+
+func (s *RewardService) ListAll(ctx context.Context) ([]model.Reward, error) {
+	return s.repo.ListRewards(ctx, false)
+}
+
+func (s *RewardService) CreateReward(ctx context.Context, reward *model.Reward) error {
+	if err := validateReward(reward); err != nil {
+		return err
+	}
+	return s.repo.CreateReward(ctx, reward)
+}
+
+func (s *RewardService) UpdateReward(ctx context.Context, reward *model.Reward) error {
+	if err := validateReward(reward); err != nil {
+		return err
+	}
+	return s.repo.UpdateReward(ctx, reward)
+}
+
+func validateReward(reward *model.Reward) error {
+	reward.Name = strings.TrimSpace(reward.Name)
+	switch {
+	case reward.Name == "" || len(reward.Name) > 100:
+		return ErrInvalidReward
+	case reward.Cost <= 0:
+		return ErrInvalidReward
+	case reward.Stock != nil && *reward.Stock < 0:
+		return ErrInvalidReward
+	case reward.PerUserLimit != nil && *reward.PerUserLimit <= 0:
+		return ErrInvalidReward
+	}
+	return nil
+}
+
+// Redeem обменивает очки пользователя на награду и создаёт заказ в статусе pending.
+func (s *RewardService) Redeem(ctx context.Context, userID string, rewardID int64) (*model.Redemption, error) {
+	order, change, err := s.repo.Redeem(ctx, userID, rewardID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(events.BalanceChanged, userID, *change)
+	return &model.Redemption{Order: *order, Balance: change.Balance}, nil
+}
+
+func (s *RewardService) FulfillOrder(ctx context.Context, orderID int64, note string) error {
+	return s.repo.FulfillOrder(ctx, orderID, note)
+}
+
+// CancelOrder отменяет заказ и возвращает очки пользователю.
+func (s *RewardService) CancelOrder(ctx context.Context, orderID int64, note string) error {
+	change, err := s.repo.CancelOrder(ctx, orderID, note)
+	if err != nil {
+		return err
+	}
+
+	s.bus.Publish(events.BalanceChanged, change.UserID, *change)
+	return nil
+}
+
+func (s *RewardService) ListOrders(ctx context.Context, userID string, status model.OrderStatus, limit, offset int) (*model.RewardOrdersPage, error) {
+	switch status {
+	case "", model.OrderPending, model.OrderFulfilled, model.OrderCancelled:
+	default:
+		return nil, ErrInvalidOrderStatus
+	}
+
+	orders, err := s.repo.ListOrders(ctx, userID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	total, err := s.repo.CountOrders(ctx, userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return &model.RewardOrdersPage{
+		Orders: orders,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSeason = errors.New("invalid season")
+
+type SeasonService struct {
+	repo     repository.SeasonRepository
+	userRepo repository.UserRepository
+	bus      *events.Bus
+}
+
+func NewSeasonService(repo repository.SeasonRepository, userRepo repository.UserRepository, bus *events.Bus) *SeasonService {
+	return &SeasonService{repo: repo, userRepo: userRepo, bus: bus}
+}
+
+func (s *SeasonService) CreateSeason(ctx context.Context, season *model.Season) error {
+	if err := validateSeason(season); err != nil {
+		return err
+	}
+
+	// Колонки TIMESTAMP хранят локальное время сервера
+	season.StartsAt = season.StartsAt.Local()
+	season.EndsAt = season.EndsAt.Local()
+
+	if err := s.repo.CreateSeason(ctx, season); err != nil {
+		return fmt.Errorf("failed to create season: %w", err)
+	}
+	return nil
+}
+
+func (s *SeasonService) ListSeasons(ctx context.Context) ([]model.Season, error) {
+	return s.repo.ListSeasons(ctx)
+}
+
+// GetStandings возвращает итоговую таблицу завершённого сезона,
+// а для текущего — положение, посчитанное на лету.
+func (s *SeasonService) GetStandings(ctx context.Context, seasonID int64, limit, offset int) (*model.SeasonStandings, error) {
+	season, err := s.repo.GetSeason(ctx, seasonID)
+	if err != nil {
+		return nil, err
+	}
+
+	standings := &model.SeasonStandings{
+		Season: *season,
+		Final:  season.SnapshottedAt != nil,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if standings.Final {
+		standings.Entries, err = s.repo.GetSnapshot(ctx, seasonID, limit, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get season snapshot: %w", err)
+		}
+		standings.Total, err = s.repo.CountSnapshot(ctx, seasonID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count season snapshot: %w", err)
+		}
+		return standings, nil
+	}
+
+	q := model.LeaderboardQuery{Since: &season.StartsAt, Until: &season.EndsAt, Limit: limit, Offset: offset}
+	entries, err := s.userRepo.GetLeaderboard(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get season leaderboard: %w", err)
+	}
+	standings.Total, err = s.userRepo.CountRanked(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count season participants: %w", err)
+	}
+
+	standings.Entries = make([]model.SeasonEntry, 0, len(entries))
+	for _, entry := range entries {
+		standings.Entries = append(standings.Entries, model.SeasonEntry{
+			LeaderboardEntry: entry,
+			RewardPoints:     rewardForPosition(season.Rewards, entry.Position),
+		})
+	}
+	return standings, nil
+}
+
+// SnapshotDue фиксирует все завершившиеся сезоны; безопасно запускать повторно.
+// Ошибка по одному сезону не останавливает остальные.
+func (s *SeasonService) SnapshotDue(ctx context.Context) error {
+	ids, err := s.repo.GetDueSeasons(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to get due seasons: %w", err)
+	}
+
+	for _, id := range ids {
+		done, err := s.repo.SnapshotSeason(ctx, id)
+		if err != nil {
+			log.Printf("Failed to snapshot season %d: %v", id, err)
+			continue
+		}
+		if done {
+			log.Printf("Season %d snapshotted", id)
+			s.publishEnded(ctx, id)
+		}
+	}
+	return nil
+}
+
+// publishEnded рассылает season_ended; по нему выдаются значки за место в сезоне.
+func (s *SeasonService) publishEnded(ctx context.Context, id int64) {
+	season, err := s.repo.GetSeason(ctx, id)
+	if err != nil {
+		log.Printf("Failed to load snapshotted season %d: %v", id, err)
+		season = &model.Season{ID: id}
+	}
+	s.bus.Publish(events.SeasonEnded, "", season)
+}
+
+func validateSeason(season *model.Season) error {
+	if strings.TrimSpace(season.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSeason)
+	}
+	if !season.EndsAt.After(season.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSeason)
+	}
+
+	lastPosition := 0
+	for _, reward := range season.Rewards {
+		if reward.PositionFrom <= lastPosition || reward.PositionTo < reward.PositionFrom {
+			return fmt.Errorf("%w: reward positions must be ascending and non-overlapping", ErrInvalidSeason)
+		}
+		if reward.Points <= 0 {
+			return fmt.Errorf("%w: reward points must be positive", ErrInvalidSeason)
+		}
+		lastPosition = reward.PositionTo
+	}
+	return nil
+}
+
+func rewardForPosition(rewards []model.SeasonReward, position int) int {
+	for _, reward := range rewards {
+		if position >= reward.PositionFrom && position <= reward.PositionTo {
+			return reward.Points
+		}
+	}
+	return 0
+}
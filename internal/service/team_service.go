@@ -0,0 +1,255 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// teamBuffer — буфер подписки команд на шину событий
+	teamBuffer        = 256
+	minTeamName       = 3
+	maxTeamName       = 50
+	maxChallengeTitle = 100
+)
+
+var (
+	ErrInvalidTeam          = errors.New("invalid team")
+	ErrInvalidTeamChallenge = errors.New("invalid team challenge")
+)
+
+type TeamService struct {
+	repo repository.TeamRepository
+	cfg  config.TeamsConfig
+	bus  *events.Bus
+}
+
+func NewTeamService(repo repository.TeamRepository, cfg config.TeamsConfig, bus *events.Bus) *TeamService {
+	return &TeamService{repo: repo, cfg: cfg, bus: bus}
+}
+
+// Run засчитывает выполненные задания в челленджи команды исполнителя до отмены ctx.
+// Прогресс считается по выполненным заданиям, а не по событиям, поэтому при отставании
+// от шины подписка просто восстанавливается: цель будет засчитана при следующем задании.
+func (s *TeamService) Run(ctx context.Context) {
+	for {
+		sub := s.bus.Subscribe(func(event events.Event) bool {
+			return event.Type == events.TaskCompleted
+		}, teamBuffer)
+		s.consume(ctx, sub)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Team challenges fell behind the event bus, resubscribing")
+	}
+}
+
+func (s *TeamService) consume(ctx context.Context, sub *events.Subscription) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if err := s.checkChallenges(ctx, event.UserID); err != nil {
+				log.Printf("Team challenge check for user %s failed: %v", event.UserID, err)
+			}
+		}
+	}
+}
+
+func (s *TeamService) checkChallenges(ctx context.Context, userID string) error {
+	teamID, err := s.repo.UserTeamID(ctx, userID)
+	if err != nil || teamID == 0 {
+		return err
+	}
+
+	completed, changes, err := s.repo.CompleteReachedChallenges(ctx, teamID)
+	if err != nil || len(completed) == 0 {
+		return err
+	}
+
+	for _, change := range changes {
+		s.bus.Publish(events.BalanceChanged, change.UserID, change)
+	}
+	members, err := s.repo.ListMembers(ctx, teamID)
+	if err != nil {
+		return err
+	}
+	for _, challenge := range completed {
+		log.Printf("Team %d completed challenge %d", teamID, challenge.ID)
+		for _, member := range members {
+			s.bus.Publish(events.TeamChallengeDone, member.UserID, challenge)
+		}
+	}
+	return nil
+}
+
+// CreateTeam создаёт команду с капитаном userID и новым кодом приглашения.
+func (s *TeamService) CreateTeam(ctx context.Context, userID, name string) (*model.Team, error) {
+	name = strings.TrimSpace(name)
+	if n := utf8.RuneCountInString(name); n < minTeamName || n > maxTeamName {
+		return nil, fmt.Errorf("%w: name must be %d-%d characters", ErrInvalidTeam, minTeamName, maxTeamName)
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	team := &model.Team{Name: name, InviteCode: code}
+	if err := s.repo.CreateTeam(ctx, team, userID); err != nil {
+		return nil, err
+	}
+	return s.GetTeam(ctx, team.ID, userID)
+}
+
+// GetTeam возвращает команду с участниками; код приглашения видят только участники.
+func (s *TeamService) GetTeam(ctx context.Context, teamID int64, viewerID string) (*model.Team, error) {
+	team, err := s.repo.GetTeam(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	team.Members, err = s.repo.ListMembers(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team members: %w", err)
+	}
+
+	member := false
+	for _, m := range team.Members {
+		if m.UserID == viewerID {
+			member = true
+			break
+		}
+	}
+	if !member {
+		team.InviteCode = ""
+	}
+	return team, nil
+}
+
+// GetUserTeam возвращает команду, в которой состоит userID.
+func (s *TeamService) GetUserTeam(ctx context.Context, userID, viewerID string) (*model.Team, error) {
+	teamID, err := s.repo.UserTeamID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user team: %w", err)
+	}
+	if teamID == 0 {
+		return nil, repository.ErrTeamNotFound
+	}
+	return s.GetTeam(ctx, teamID, viewerID)
+}
+
+func (s *TeamService) JoinTeam(ctx context.Context, userID, inviteCode string) (*model.Team, error) {
+	teamID, err := s.repo.JoinTeam(ctx, normalizePromoCode(inviteCode), userID, s.cfg.MaxMembers)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTeam(ctx, teamID, userID)
+}
+
+// LeaveTeam выводит пользователя из команды. Ушедший капитан передаёт роль самому
+// давнему участнику, последний ушедший распускает команду.
+func (s *TeamService) LeaveTeam(ctx context.Context, userID string, teamID int64) error {
+	return s.repo.LeaveTeam(ctx, teamID, userID)
+}
+
+func (s *TeamService) RemoveMember(ctx context.Context, captainID string, teamID int64, memberID string) error {
+	if memberID == captainID {
+		return fmt.Errorf("%w: captain cannot remove themselves, leave the team instead", ErrInvalidTeam)
+	}
+	return s.repo.RemoveMember(ctx, teamID, captainID, memberID)
+}
+
+func (s *TeamService) SetCaptain(ctx context.Context, captainID string, teamID int64, newCaptainID string) error {
+	if newCaptainID == "" {
+		return fmt.Errorf("%w: user_id is required", ErrInvalidTeam)
+	}
+	return s.repo.SetCaptain(ctx, teamID, captainID, newCaptainID)
+}
+
+// RotateInviteCode выдаёт команде новый код приглашения; старый перестаёт действовать.
+func (s *TeamService) RotateInviteCode(ctx context.Context, captainID string, teamID int64) (string, error) {
+	code, err := newInviteCode()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.SetInviteCode(ctx, teamID, captainID, code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *TeamService) GetLeaderboard(ctx context.Context, limit, offset int) (*model.TeamLeaderboardPage, error) {
+	entries, err := s.repo.GetLeaderboard(ctx, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team leaderboard: %w", err)
+	}
+
+	total, err := s.repo.CountTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count teams: %w", err)
+	}
+
+	return &model.TeamLeaderboardPage{
+		Entries: entries,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+	}, nil
+}
+
+// CreateChallenge заводит челлендж команды; без starts_at он начинается сразу.
+func (s *TeamService) CreateChallenge(ctx context.Context, challenge *model.TeamChallenge) error {
+	challenge.Title = strings.TrimSpace(challenge.Title)
+	challenge.Task = strings.TrimSpace(challenge.Task)
+	if challenge.StartsAt.IsZero() {
+		challenge.StartsAt = time.Now()
+	}
+
+	switch {
+	case challenge.Title == "" || len(challenge.Title) > maxChallengeTitle:
+		return fmt.Errorf("%w: title must be 1-%d characters", ErrInvalidTeamChallenge, maxChallengeTitle)
+	case challenge.Goal <= 0:
+		return fmt.Errorf("%w: goal must be positive", ErrInvalidTeamChallenge)
+	case challenge.RewardPoints < 0:
+		return fmt.Errorf("%w: reward_points must not be negative", ErrInvalidTeamChallenge)
+	case !challenge.EndsAt.After(challenge.StartsAt):
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidTeamChallenge)
+	}
+
+	// Колонки TIMESTAMP хранят локальное время сервера
+	challenge.StartsAt = challenge.StartsAt.Local()
+	challenge.EndsAt = challenge.EndsAt.Local()
+
+	return s.repo.CreateChallenge(ctx, challenge)
+}
+
+func (s *TeamService) ListChallenges(ctx context.Context, teamID int64) ([]model.TeamChallenge, error) {
+	if _, err := s.repo.GetTeam(ctx, teamID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListChallenges(ctx, teamID)
+}
+
+func newInviteCode() (string, error) {
+	codes, err := generatePromoCodes("", 1)
+	if err != nil {
+		return "", err
+	}
+	return codes[0], nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	maxTransferMemo       = 200
+	maxIdempotencyKeySize = 64
+)
+
+var ErrInvalidTransfer = errors.New("invalid transfer")
+
+type TransferService struct {
+	repo repository.TransferRepository
+	cfg  config.TransfersConfig
+	bus  *events.Bus
+}
+
+func NewTransferService(repo repository.TransferRepository, cfg config.TransfersConfig, bus *events.Bus) *TransferService {
+	return &TransferService{repo: repo, cfg: cfg, bus: bus}
+}
+
+// Transfer переводит очки от senderID получателю. idempotencyKey обязателен:
+// повторный запрос с тем же ключом не списывает очки второй раз.
+func (s *TransferService) Transfer(ctx context.Context, senderID, recipientID string, amount int, memo, idempotencyKey string) (*model.TransferResult, error) {
+	memo = strings.TrimSpace(memo)
+	switch {
+	case recipientID == "" || recipientID == senderID:
+		return nil, fmt.Errorf("%w: recipient must be another user", ErrInvalidTransfer)
+	case amount <= 0:
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTransfer)
+	case len(memo) > maxTransferMemo:
+		return nil, fmt.Errorf("%w: memo must be at most %d characters", ErrInvalidTransfer, maxTransferMemo)
+	case idempotencyKey == "" || len(idempotencyKey) > maxIdempotencyKeySize:
+		return nil, fmt.Errorf("%w: idempotency key must be 1-%d characters", ErrInvalidTransfer, maxIdempotencyKeySize)
+	}
+
+	result, err := s.repo.CreateTransfer(ctx, &model.Transfer{
+		SenderID:    senderID,
+		RecipientID: recipientID,
+		Amount:      amount,
+		Memo:        memo,
+	}, idempotencyKey, s.cfg.DailyAmount, s.cfg.DailyCount)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Replayed {
+		for _, change := range result.Changes {
+			s.bus.Publish(events.BalanceChanged, change.UserID, change)
+		}
+		s.bus.Publish(events.TransferReceived, recipientID, result.Transfer)
+	}
+	return result, nil
+}
+
+func (s *TransferService) ListTransfers(ctx context.Context, userID string, limit, offset int) (*model.TransfersPage, error) {
+	transfers, err := s.repo.ListTransfers(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transfers: %w", err)
+	}
+
+	total, err := s.repo.CountTransfers(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count transfers: %w", err)
+	}
+
+	return &model.TransfersPage{
+		Transfers: transfers,
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}, nil
+}
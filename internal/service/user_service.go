@@ -1,25 +1,55 @@
 package service
 
 import (
+	"Test/internal/events"
+	"Test/internal/levels"
 	"Test/internal/model"
 	"Test/internal/repository"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+)
+
+const maxCohortValueLength = 100
+
+var (
+	ErrInvalidFriend      = errors.New("cannot add yourself as a friend")
+	ErrInvalidCohortKind  = errors.New("cohort kind must be 1-32 characters and cannot be signup_month")
+	ErrInvalidCohortValue = errors.New("cohort value must be at most 100 characters")
 )
 
 type UserService struct {
-	userRepo repository.UserRepository
-	taskRepo repository.TaskRepository
+	userRepo        repository.UserRepository
+	taskRepo        repository.TaskRepository
+	commissionRates []float64
+	bus             *events.Bus
+	expiry          *ExpiryService
+	levels          *levels.Curve
+	badges          *BadgeService
+	checkins        *CheckinService
 }
 
-func NewUserService(userRepo repository.UserRepository, taskRepo repository.TaskRepository) *UserService {
+func NewUserService(userRepo repository.UserRepository, taskRepo repository.TaskRepository, commissionRates []float64, bus *events.Bus, expiry *ExpiryService, curve *levels.Curve, badges *BadgeService, checkins *CheckinService) *UserService {
 	return &UserService{
-		userRepo: userRepo,
-		taskRepo: taskRepo,
+		userRepo:        userRepo,
+		taskRepo:        taskRepo,
+		commissionRates: commissionRates,
+		bus:             bus,
+		expiry:          expiry,
+		levels:          curve,
+		badges:          badges,
+		checkins:        checkins,
 	}
 }
 
-func (s *UserService) GetUserStatus(ctx context.Context, userID string) (*model.UserStatus, error) {
+// GetUserStatus возвращает статус пользователя с балансом в валюте currency.
+func (s *UserService) GetUserStatus(ctx context.Context, userID string, currency model.Currency) (*model.UserStatus, error) {
+	currency, err := validateCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetUserByID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
@@ -30,37 +60,138 @@ func (s *UserService) GetUserStatus(ctx context.Context, userID string) (*model.
 		return nil, fmt.Errorf("failed to get completed tasks: %w", err)
 	}
 
-	var referrals []model.User
-	if user.Referrer != nil {
-		referrals, err = s.userRepo.GetReferrals(ctx, *user.Referrer)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get referrals: %w", err)
-		}
+	referrals, err := s.userRepo.GetReferrals(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get referrals: %w", err)
+	}
+
+	badges, err := s.badges.ListUserBadges(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badges: %w", err)
+	}
+
+	streak, err := s.checkins.GetStreak(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get streak: %w", err)
 	}
 
-	return &model.UserStatus{
+	status := &model.UserStatus{
 		User:           *user,
 		CompletedTasks: tasks,
 		Referrals:      referrals,
+		Currency:       currency,
+		Balance:        user.Points,
+		Level:          s.levels.Status(user.XP),
+		Badges:         badges,
+		Streak:         streak,
+	}
+	if currency == model.CurrencyXP {
+		status.Balance = user.XP
+		return status, nil
+	}
+
+	// Сгорают только очки: опыт не тратится и не сгорает
+	status.ExpiringSoon, err = s.expiry.ExpiringSoon(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expiring points: %w", err)
+	}
+	return status, nil
+}
+
+// GetProfile возвращает публичный профиль: имя, уровень и значки, без почты и балансов.
+func (s *UserService) GetProfile(ctx context.Context, userID string) (*model.PublicProfile, error) {
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	badges, err := s.badges.ListUserBadges(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badges: %w", err)
+	}
+
+	return &model.PublicProfile{
+		UserID: user.ID,
+		Name:   user.Name,
+		Level:  s.levels.Status(user.XP),
+		Badges: badges,
 	}, nil
 }
 
+// CompleteTask начисляет награду за задание с множителем тира пользователя
+// и публикует level_up, если опыт перешёл порог уровня.
 func (s *UserService) CompleteTask(ctx context.Context, userID, taskID string) error {
-	return s.taskRepo.CompleteTask(ctx, userID, taskID)
-}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
 
-func (s *UserService) SetReferrer(ctx context.Context, userID, referrerID string) error {
-	if _, err := s.userRepo.GetUserByID(ctx, referrerID); err != nil {
+	completion, err := s.taskRepo.CompleteTask(ctx, userID, taskID, s.commissionRates, s.levels.Multiplier(user.XP))
+	if err != nil {
 		return err
 	}
 
-	if err := s.userRepo.UpdateUserPoints(ctx, referrerID, 100); err != nil {
-		return err
+	s.bus.Publish(events.TaskCompleted, userID, completion.Task)
+	for _, change := range completion.Changes {
+		s.bus.Publish(events.BalanceChanged, change.UserID, change)
+		if change.UserID == userID && change.Currency == model.CurrencyXP {
+			s.publishLevelUp(userID, change.Balance-change.Delta, change.Balance)
+		}
+	}
+	return nil
+}
+
+func (s *UserService) publishLevelUp(userID string, xpBefore, xpAfter int) {
+	if !s.levels.Enabled() {
+		return
+	}
+
+	before, after := s.levels.Level(xpBefore), s.levels.Level(xpAfter)
+	if after <= before {
+		return
 	}
 
-	return s.userRepo.SetReferrer(ctx, userID, referrerID)
+	levelUp := model.LevelUp{Level: after, PreviousLevel: before}
+	if tier, ok := s.levels.Tier(after); ok {
+		levelUp.Tier = tier.Name
+	}
+	s.bus.Publish(events.LevelUp, userID, levelUp)
+}
+
+func (s *UserService) AddFriend(ctx context.Context, userID, friendID string) error {
+	if userID == friendID {
+		return ErrInvalidFriend
+	}
+	return s.userRepo.AddFriend(ctx, userID, friendID)
 }
 
-func (s *UserService) GetLeaderboard(ctx context.Context, limit int) ([]model.LeaderboardEntry, error) {
-	return s.userRepo.GetLeaderboard(ctx, limit)
+func (s *UserService) RemoveFriend(ctx context.Context, userID, friendID string) error {
+	return s.userRepo.RemoveFriend(ctx, userID, friendID)
+}
+
+func (s *UserService) ListFriends(ctx context.Context, userID string) ([]model.Friend, error) {
+	return s.userRepo.ListFriends(ctx, userID)
+}
+
+// SetEmailVerified — подтверждение email; от него зависит квалификация реферала
+// при referral.require_verified_email.
+func (s *UserService) SetEmailVerified(ctx context.Context, userID string, verified bool) error {
+	return s.userRepo.SetEmailVerified(ctx, userID, verified)
+}
+
+// SetCohort включает пользователя в когорту (страна, команда и т.п.); signup_month вычисляется
+// из даты регистрации и задать её нельзя.
+func (s *UserService) SetCohort(ctx context.Context, userID, kind, value string) error {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind == "" || len(kind) > maxCohortLength || kind == model.CohortSignupMonth {
+		return ErrInvalidCohortKind
+	}
+	value = strings.TrimSpace(value)
+	if len(value) > maxCohortValueLength {
+		return ErrInvalidCohortValue
+	}
+	if kind == model.CohortCountry {
+		value = strings.ToUpper(value)
+	}
+	return s.userRepo.SetCohort(ctx, userID, kind, value)
 }
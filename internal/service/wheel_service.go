@@ -0,0 +1,216 @@
+package service
+
+import (
+	"Test/config"
+	"Test/internal/events"
+	"Test/internal/fairness"
+	"Test/internal/model"
+	"Test/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	serverSeedBytes = 32
+	clientSeedBytes = 8
+	maxWheelPrizes  = 20
+	maxPrizeLabel   = 50
+)
+
+var (
+	ErrInvalidClientSeed = errors.New("invalid client seed")
+	ErrInvalidWheel      = errors.New("invalid prize table")
+)
+
+var clientSeedPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type WheelService struct {
+	repo repository.WheelRepository
+	cfg  config.WheelConfig
+	loc  *time.Location
+	bus  *events.Bus
+}
+
+func NewWheelService(repo repository.WheelRepository, cfg config.WheelConfig, bus *events.Bus) (*WheelService, error) {
+	if cfg.Timezone == "" {
+		cfg.Timezone = defaultCheckinTimezone
+	}
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("load wheel timezone: %w", err)
+	}
+	return &WheelService{repo: repo, cfg: cfg, loc: loc, bus: bus}, nil
+}
+
+// GetTable возвращает текущую таблицу призов с вероятностями и матожиданием выигрыша.
+func (s *WheelService) GetTable(ctx context.Context) (*model.WheelTable, error) {
+	table, err := s.repo.GetActiveTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.describe(table)
+	return table, nil
+}
+
+// SetPrizes заменяет таблицу призов новой версией; прошлые вращения остаются привязаны к своей.
+func (s *WheelService) SetPrizes(ctx context.Context, prizes []model.WheelPrize) (*model.WheelTable, error) {
+	if len(prizes) == 0 || len(prizes) > maxWheelPrizes {
+		return nil, fmt.Errorf("%w: table must have 1-%d prizes", ErrInvalidWheel, maxWheelPrizes)
+	}
+	for i := range prizes {
+		prizes[i].Label = strings.TrimSpace(prizes[i].Label)
+		switch {
+		case prizes[i].Label == "" || len(prizes[i].Label) > maxPrizeLabel:
+			return nil, fmt.Errorf("%w: prize label must be 1-%d characters", ErrInvalidWheel, maxPrizeLabel)
+		case prizes[i].Points < 0:
+			return nil, fmt.Errorf("%w: prize points must not be negative", ErrInvalidWheel)
+		case prizes[i].Weight <= 0:
+			return nil, fmt.Errorf("%w: prize weight must be positive", ErrInvalidWheel)
+		}
+	}
+
+	table, err := s.repo.CreateTable(ctx, prizes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prize table: %w", err)
+	}
+	s.describe(table)
+	return table, nil
+}
+
+// Spin вращает колесо за пользователя: бесплатно, если дневной лимит не исчерпан, иначе за SpinCost очков.
+func (s *WheelService) Spin(ctx context.Context, userID string) (*model.WheelSpin, error) {
+	candidate, err := newWheelSeed("")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().In(s.loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, s.loc).Local()
+
+	spin, err := s.repo.Spin(ctx, userID, candidate, s.cfg.SpinCost, dayStart, s.cfg.FreeSpinsPerDay)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range spin.Changes {
+		s.bus.Publish(events.BalanceChanged, userID, change)
+	}
+	return spin, nil
+}
+
+// GetSeed возвращает хеш текущего серверного сида, клиентский сид и следующий nonce.
+func (s *WheelService) GetSeed(ctx context.Context, userID string) (*model.WheelSeed, error) {
+	candidate, err := newWheelSeed("")
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetActiveSeed(ctx, userID, candidate)
+}
+
+// RotateSeed раскрывает текущий серверный сид и заводит новую пару с clientSeed
+// (пустой — сгенерировать). Возвращает раскрытую и новую пары.
+func (s *WheelService) RotateSeed(ctx context.Context, userID, clientSeed string) (revealed, next *model.WheelSeed, err error) {
+	if clientSeed != "" && !clientSeedPattern.MatchString(clientSeed) {
+		return nil, nil, fmt.Errorf("%w: must be 1-64 letters, digits, '_' or '-'", ErrInvalidClientSeed)
+	}
+
+	next, err = newWheelSeed(clientSeed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revealed, err = s.repo.RotateSeed(ctx, userID, next)
+	if err != nil {
+		return nil, nil, err
+	}
+	next.ServerSeed = ""
+	return revealed, next, nil
+}
+
+// ListSpins возвращает журнал вращений; пустой userID — вращения всех пользователей.
+func (s *WheelService) ListSpins(ctx context.Context, userID string, limit, offset int) (*model.WheelSpinsPage, error) {
+	spins, err := s.repo.ListSpins(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list wheel spins: %w", err)
+	}
+
+	total, err := s.repo.CountSpins(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count wheel spins: %w", err)
+	}
+
+	return &model.WheelSpinsPage{
+		Spins:  spins,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}, nil
+}
+
+// Report сравнивает ожидаемую отдачу текущей таблицы с фактической.
+func (s *WheelService) Report(ctx context.Context) (*model.WheelReport, error) {
+	table, err := s.GetTable(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report, hits, err := s.repo.GetTableStats(ctx, table.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wheel stats: %w", err)
+	}
+
+	report.SpinCost = table.SpinCost
+	report.ExpectedValue = table.ExpectedValue
+	if table.SpinCost > 0 {
+		report.ExpectedReturn = table.ExpectedValue / float64(table.SpinCost)
+	}
+	if report.PointsSpent > 0 {
+		report.ActualReturn = float64(report.PointsPaid) / float64(report.PointsSpent)
+	}
+
+	report.Prizes = make([]model.WheelPrizeStats, 0, len(table.Prizes))
+	for _, prize := range table.Prizes {
+		report.Prizes = append(report.Prizes, model.WheelPrizeStats{
+			WheelPrize:   prize,
+			Hits:         hits[prize.Position],
+			ExpectedHits: prize.Probability * float64(report.Spins),
+		})
+	}
+	return report, nil
+}
+
+// describe дополняет таблицу вероятностями призов, матожиданием и стоимостью вращения.
+func (s *WheelService) describe(table *model.WheelTable) {
+	total := 0
+	for _, prize := range table.Prizes {
+		total += prize.Weight
+	}
+
+	table.SpinCost = s.cfg.SpinCost
+	table.ExpectedValue = 0
+	for i := range table.Prizes {
+		table.Prizes[i].Probability = float64(table.Prizes[i].Weight) / float64(total)
+		table.ExpectedValue += table.Prizes[i].Probability * float64(table.Prizes[i].Points)
+	}
+}
+
+func newWheelSeed(clientSeed string) (*model.WheelSeed, error) {
+	serverSeed, err := fairness.NewSeed(serverSeedBytes)
+	if err != nil {
+		return nil, err
+	}
+	if clientSeed == "" {
+		if clientSeed, err = fairness.NewSeed(clientSeedBytes); err != nil {
+			return nil, err
+		}
+	}
+	return &model.WheelSeed{
+		ServerSeed:     serverSeed,
+		ServerSeedHash: fairness.Hash(serverSeed),
+		ClientSeed:     clientSeed,
+	}, nil
+}
@@ -0,0 +1,22 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+CREATE TABLE referrals (
+    referee_id VARCHAR(36) PRIMARY KEY,
+    referrer_id VARCHAR(36) NOT NULL,
+    date TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reward_points INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    resolved_at TIMESTAMP,
+    resolution TEXT,
+    FOREIGN KEY (referee_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_referrals_referrer ON referrals (referrer_id);
+CREATE INDEX idx_referrals_pending ON referrals (date) WHERE status = 'pending';
+
+-- Рефералы, заведённые до появления таблицы, уже были оплачены
+INSERT INTO referrals (referee_id, referrer_id, date, reward_points, status, resolved_at, resolution)
+SELECT id, referrer, updated_at, 100, 'paid', updated_at, 'paid before deferred rewards'
+FROM users
+WHERE referrer IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_referrer;
+DROP TABLE IF EXISTS referral_commissions;
@@ -0,0 +1,15 @@
+CREATE TABLE referral_commissions (
+    id SERIAL PRIMARY KEY,
+    beneficiary_id VARCHAR(36) NOT NULL,
+    source_user_id VARCHAR(36) NOT NULL,
+    task_id VARCHAR(36) NOT NULL,
+    level INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (beneficiary_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (source_user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (task_id) REFERENCES tasks(id)
+);
+
+CREATE INDEX idx_referral_commissions_beneficiary ON referral_commissions (beneficiary_id, level);
+CREATE INDEX idx_users_referrer ON users (referrer);
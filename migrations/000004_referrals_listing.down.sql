@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_referral_commissions_source;
+DROP INDEX IF EXISTS idx_referrals_referrer_date;
+CREATE INDEX idx_referrals_referrer ON referrals (referrer_id);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_referrals_referrer;
+CREATE INDEX idx_referrals_referrer_date ON referrals (referrer_id, date DESC);
+CREATE INDEX idx_referral_commissions_source ON referral_commissions (beneficiary_id, source_user_id);
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS idx_referrals_review;
+ALTER TABLE referrals DROP COLUMN IF EXISTS flags;
+
+DROP INDEX IF EXISTS idx_users_device_id;
+DROP INDEX IF EXISTS idx_users_registration_ip;
+DROP INDEX IF EXISTS idx_users_normalized_email;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS device_id,
+    DROP COLUMN IF EXISTS registration_user_agent,
+    DROP COLUMN IF EXISTS registration_ip,
+    DROP COLUMN IF EXISTS normalized_email,
+    DROP COLUMN IF EXISTS is_admin;
@@ -0,0 +1,21 @@
+ALTER TABLE users
+    ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN normalized_email VARCHAR(100),
+    ADD COLUMN registration_ip VARCHAR(45),
+    ADD COLUMN registration_user_agent TEXT,
+    ADD COLUMN device_id VARCHAR(100);
+
+-- Точки и +алиасы в локальной части gmail указывают на один и тот же ящик
+UPDATE users SET normalized_email = CASE
+    WHEN split_part(lower(email), '@', 2) IN ('gmail.com', 'googlemail.com')
+        THEN replace(split_part(split_part(lower(email), '@', 1), '+', 1), '.', '') || '@gmail.com'
+    ELSE split_part(split_part(lower(email), '@', 1), '+', 1) || '@' || split_part(lower(email), '@', 2)
+END;
+
+CREATE INDEX idx_users_normalized_email ON users (normalized_email);
+CREATE INDEX idx_users_registration_ip ON users (registration_ip);
+CREATE INDEX idx_users_device_id ON users (device_id);
+
+ALTER TABLE referrals ADD COLUMN flags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_referrals_review ON referrals (date) WHERE status = 'review';
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_referrals_resolved;
+DROP INDEX IF EXISTS idx_referrals_date;
+DROP TABLE IF EXISTS referral_clicks;
+DROP TABLE IF EXISTS points_ledger;
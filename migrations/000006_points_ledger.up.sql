@@ -0,0 +1,32 @@
+CREATE TABLE points_ledger (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL,
+    amount INTEGER NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_points_ledger_user ON points_ledger (user_id, created_at);
+CREATE INDEX idx_points_ledger_reason ON points_ledger (reason, created_at);
+
+-- Баланс, накопленный до появления журнала, фиксируется одной записью
+INSERT INTO points_ledger (user_id, amount, reason, created_at)
+SELECT id, points, 'opening_balance', updated_at
+FROM users
+WHERE points <> 0;
+
+CREATE TABLE referral_clicks (
+    id BIGSERIAL PRIMARY KEY,
+    referrer_id VARCHAR(36) NOT NULL,
+    ip VARCHAR(45),
+    user_agent TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (referrer_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_referral_clicks_referrer ON referral_clicks (referrer_id, created_at);
+CREATE INDEX idx_referral_clicks_created ON referral_clicks (created_at);
+CREATE INDEX idx_referrals_date ON referrals (date);
+CREATE INDEX idx_referrals_resolved ON referrals (resolved_at) WHERE status = 'paid';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_leaderboard;
+ALTER TABLE users DROP COLUMN IF EXISTS points_reached_at;
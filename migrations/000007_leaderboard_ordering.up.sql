@@ -0,0 +1,7 @@
+-- Момент, когда пользователь достиг текущего баланса: при равенстве очков выше тот, кто успел раньше
+ALTER TABLE users ADD COLUMN points_reached_at TIMESTAMP;
+UPDATE users SET points_reached_at = updated_at;
+ALTER TABLE users ALTER COLUMN points_reached_at SET NOT NULL;
+ALTER TABLE users ALTER COLUMN points_reached_at SET DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX idx_users_leaderboard ON users (points DESC, points_reached_at ASC, id ASC);
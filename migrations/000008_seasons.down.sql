@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS season_snapshots;
+DROP TABLE IF EXISTS season_rewards;
+DROP TABLE IF EXISTS seasons;
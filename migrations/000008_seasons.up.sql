@@ -0,0 +1,34 @@
+CREATE TABLE seasons (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    snapshotted_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX idx_seasons_due ON seasons (ends_at) WHERE snapshotted_at IS NULL;
+
+CREATE TABLE season_rewards (
+    season_id INTEGER NOT NULL,
+    position_from INTEGER NOT NULL,
+    position_to INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    PRIMARY KEY (season_id, position_from),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+    CHECK (position_from >= 1 AND position_to >= position_from AND points > 0)
+);
+
+CREATE TABLE season_snapshots (
+    season_id INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    reward_points INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (season_id, user_id),
+    FOREIGN KEY (season_id) REFERENCES seasons(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_season_snapshots_position ON season_snapshots (season_id, position);
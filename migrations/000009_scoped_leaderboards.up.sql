@@ -0,0 +1,17 @@
+CREATE TABLE friendships (
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    friend_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, friend_id),
+    CHECK (user_id <> friend_id)
+);
+
+-- Произвольные когорты пользователя: страна, команда и т.п.; одно значение на вид
+CREATE TABLE user_cohorts (
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    value VARCHAR(100) NOT NULL,
+    PRIMARY KEY (user_id, kind)
+);
+
+CREATE INDEX idx_user_cohorts_kind_value ON user_cohorts(kind, value);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_users_hidden;
+ALTER TABLE users DROP COLUMN IF EXISTS leaderboard_visibility;
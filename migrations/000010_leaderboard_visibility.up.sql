@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN leaderboard_visibility VARCHAR(16) NOT NULL DEFAULT 'visible'
+    CHECK (leaderboard_visibility IN ('visible', 'excluded', 'shadow'));
+
+CREATE INDEX idx_users_hidden ON users(leaderboard_visibility) WHERE leaderboard_visibility <> 'visible';
@@ -0,0 +1,29 @@
+CREATE TABLE rewards (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cost INTEGER NOT NULL CHECK (cost > 0),
+    -- NULL — без ограничений
+    stock INTEGER CHECK (stock >= 0),
+    per_user_limit INTEGER CHECK (per_user_limit > 0),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE reward_orders (
+    id BIGSERIAL PRIMARY KEY,
+    reward_id BIGINT NOT NULL REFERENCES rewards(id),
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cost INTEGER NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'fulfilled', 'cancelled')),
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    fulfilled_at TIMESTAMP,
+    cancelled_at TIMESTAMP
+);
+
+CREATE INDEX idx_reward_orders_user ON reward_orders(user_id, created_at DESC);
+CREATE INDEX idx_reward_orders_user_reward ON reward_orders(user_id, reward_id) WHERE status <> 'cancelled';
+CREATE INDEX idx_reward_orders_status ON reward_orders(status, created_at);
@@ -0,0 +1,33 @@
+-- Партия промокодов: одна заданная вручную строка или N сгенерированных кодов с общими условиями
+CREATE TABLE promo_batches (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    points INTEGER NOT NULL CHECK (points > 0),
+    -- сколько раз можно активировать каждый код; NULL — без ограничений
+    max_uses INTEGER CHECK (max_uses > 0),
+    -- сколько кодов партии может активировать один пользователь
+    max_uses_per_user INTEGER NOT NULL DEFAULT 1 CHECK (max_uses_per_user > 0),
+    valid_from TIMESTAMP,
+    valid_until TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE promo_codes (
+    code VARCHAR(32) PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES promo_batches(id) ON DELETE CASCADE,
+    uses INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_promo_codes_batch ON promo_codes(batch_id);
+
+CREATE TABLE promo_redemptions (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(32) NOT NULL REFERENCES promo_codes(code) ON DELETE CASCADE,
+    batch_id BIGINT NOT NULL REFERENCES promo_batches(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    points INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_promo_redemptions_batch_user ON promo_redemptions(batch_id, user_id);
@@ -0,0 +1,14 @@
+CREATE TABLE transfers (
+    id BIGSERIAL PRIMARY KEY,
+    sender_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    recipient_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    memo VARCHAR(200) NOT NULL DEFAULT '',
+    idempotency_key VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (sender_id, idempotency_key),
+    CHECK (sender_id <> recipient_id)
+);
+
+CREATE INDEX idx_transfers_sender ON transfers(sender_id, created_at);
+CREATE INDEX idx_transfers_recipient ON transfers(recipient_id, created_at);
@@ -0,0 +1,18 @@
+-- Партии начисленных очков; списания расходуют их в порядке начисления (FIFO)
+CREATE TABLE point_lots (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    remaining INTEGER NOT NULL CHECK (remaining >= 0 AND remaining <= amount),
+    reason VARCHAR(50) NOT NULL,
+    earned_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_point_lots_open ON point_lots (user_id, earned_at, id) WHERE remaining > 0;
+CREATE INDEX idx_point_lots_expiry ON point_lots (earned_at) WHERE remaining > 0;
+
+-- Накопленный баланс становится одной партией: срок его сгорания отсчитывается от миграции
+INSERT INTO point_lots (user_id, amount, remaining, reason, earned_at)
+SELECT id, points, points, 'opening_balance', LOCALTIMESTAMP
+FROM users
+WHERE points > 0;
@@ -0,0 +1,13 @@
+ALTER TABLE tasks ADD COLUMN points INTEGER NOT NULL DEFAULT 0;
+UPDATE tasks t SET points = r.amount
+FROM task_rewards r
+WHERE r.task_id = t.id AND r.currency = 'points';
+
+DROP TABLE IF EXISTS task_rewards;
+
+DELETE FROM points_ledger WHERE currency <> 'points';
+ALTER TABLE points_ledger DROP COLUMN currency;
+
+DROP INDEX IF EXISTS idx_users_xp_leaderboard;
+ALTER TABLE users DROP COLUMN xp_reached_at;
+ALTER TABLE users DROP COLUMN xp;
@@ -0,0 +1,36 @@
+-- Опыт (xp) — вторая валюта: только растёт и не тратится
+ALTER TABLE users ADD COLUMN xp INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN xp_reached_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE points_ledger ADD COLUMN currency VARCHAR(20) NOT NULL DEFAULT 'points';
+
+CREATE TABLE task_rewards (
+    task_id VARCHAR(36) NOT NULL REFERENCES tasks(id) ON DELETE CASCADE,
+    currency VARCHAR(20) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (task_id, currency)
+);
+
+-- Задания начисляют прежние очки и столько же опыта
+INSERT INTO task_rewards (task_id, currency, amount)
+SELECT id, c.currency, points FROM tasks CROSS JOIN (VALUES ('points'), ('xp')) AS c(currency)
+WHERE points > 0;
+
+-- Опыт за уже выполненные задания
+INSERT INTO points_ledger (user_id, amount, reason, reference_id, created_at, currency)
+SELECT ut.user_id, t.points, 'task', t.id, ut.completed_at, 'xp'
+FROM user_tasks ut
+JOIN tasks t ON t.id = ut.task_id
+WHERE t.points > 0;
+
+UPDATE users u SET xp = x.xp, xp_reached_at = x.reached_at
+FROM (
+    SELECT user_id, SUM(amount) AS xp, MAX(created_at) AS reached_at
+    FROM points_ledger WHERE currency = 'xp'
+    GROUP BY user_id
+) x
+WHERE u.id = x.user_id;
+
+ALTER TABLE tasks DROP COLUMN points;
+
+CREATE INDEX idx_users_xp_leaderboard ON users (xp DESC, xp_reached_at ASC, id ASC);
@@ -0,0 +1,23 @@
+CREATE TABLE badges (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    rule VARCHAR(32) NOT NULL,
+    threshold INTEGER NOT NULL CHECK (threshold > 0),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_badges_rule ON badges (rule);
+
+CREATE TABLE user_badges (
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge_id VARCHAR(50) NOT NULL REFERENCES badges(id) ON DELETE CASCADE,
+    awarded_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, badge_id)
+);
+
+INSERT INTO badges (id, name, description, rule, threshold) VALUES
+('first_task', 'Первое задание', 'Выполнить первое задание', 'tasks_completed', 1),
+('five_referrals', '5 рефералов', 'Пригласить пять друзей', 'referrals', 5),
+('week_streak', '7 дней подряд', 'Выполнять задания семь дней подряд', 'task_streak', 7),
+('season_top_100', 'Топ-100 сезона', 'Войти в первую сотню итоговой таблицы сезона', 'season_rank', 100);
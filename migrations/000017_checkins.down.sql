@@ -0,0 +1,3 @@
+DELETE FROM badges WHERE rule = 'checkin_streak';
+DROP TABLE IF EXISTS checkins;
+DROP TABLE IF EXISTS user_streaks;
@@ -0,0 +1,23 @@
+CREATE TABLE user_streaks (
+    user_id VARCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    current INTEGER NOT NULL DEFAULT 0,
+    longest INTEGER NOT NULL DEFAULT 0,
+    -- Дата последней отметки в часовом поясе пользователя
+    last_checkin DATE,
+    freezes INTEGER NOT NULL DEFAULT 0 CHECK (freezes >= 0),
+    timezone VARCHAR(64) NOT NULL
+);
+
+CREATE TABLE checkins (
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    streak INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    freezes_used INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, day)
+);
+
+INSERT INTO badges (id, name, description, rule, threshold) VALUES
+('checkin_week', 'Неделя отметок', 'Отмечаться семь дней подряд', 'checkin_streak', 7),
+('checkin_month', 'Месяц отметок', 'Отмечаться тридцать дней подряд', 'checkin_streak', 30);
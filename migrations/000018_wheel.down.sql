@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS wheel_spins;
+DROP TABLE IF EXISTS wheel_seeds;
+DROP TABLE IF EXISTS wheel_prizes;
+DROP TABLE IF EXISTS wheel_tables;
@@ -0,0 +1,56 @@
+-- Таблицы призов неизменяемы: новая настройка шансов создаёт новую версию,
+-- а каждое вращение ссылается на версию, по которой разыгрывалось
+CREATE TABLE wheel_tables (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE wheel_prizes (
+    table_id INTEGER NOT NULL REFERENCES wheel_tables(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    label VARCHAR(50) NOT NULL,
+    points INTEGER NOT NULL CHECK (points >= 0),
+    weight INTEGER NOT NULL CHECK (weight > 0),
+    PRIMARY KEY (table_id, position)
+);
+
+CREATE TABLE wheel_seeds (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    server_seed CHAR(64) NOT NULL,
+    server_seed_hash CHAR(64) NOT NULL,
+    client_seed VARCHAR(64) NOT NULL,
+    nonce INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    revealed_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_wheel_seeds_active ON wheel_seeds (user_id) WHERE revealed_at IS NULL;
+
+CREATE TABLE wheel_spins (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    seed_id BIGINT NOT NULL REFERENCES wheel_seeds(id),
+    nonce INTEGER NOT NULL,
+    digest CHAR(64) NOT NULL,
+    table_id INTEGER NOT NULL REFERENCES wheel_tables(id),
+    position INTEGER NOT NULL,
+    points INTEGER NOT NULL,
+    cost INTEGER NOT NULL,
+    free BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    UNIQUE (seed_id, nonce)
+);
+
+CREATE INDEX idx_wheel_spins_user ON wheel_spins (user_id, created_at);
+CREATE INDEX idx_wheel_spins_table ON wheel_spins (table_id);
+
+INSERT INTO wheel_tables (id, created_at) VALUES (1, LOCALTIMESTAMP);
+SELECT setval('wheel_tables_id_seq', 1);
+INSERT INTO wheel_prizes (table_id, position, label, points, weight) VALUES
+(1, 1, 'Пусто', 0, 40),
+(1, 2, '10 очков', 10, 30),
+(1, 3, '25 очков', 25, 15),
+(1, 4, '50 очков', 50, 10),
+(1, 5, '100 очков', 100, 4),
+(1, 6, 'Джекпот', 500, 1);
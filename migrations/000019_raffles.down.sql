@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS raffle_winners;
+DROP TABLE IF EXISTS raffle_tickets;
+DROP TABLE IF EXISTS raffles;
@@ -0,0 +1,41 @@
+CREATE TABLE raffles (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    prize VARCHAR(200) NOT NULL,
+    prize_points INTEGER NOT NULL DEFAULT 0 CHECK (prize_points >= 0),
+    ticket_price INTEGER NOT NULL CHECK (ticket_price > 0),
+    max_tickets_per_user INTEGER NOT NULL DEFAULT 0 CHECK (max_tickets_per_user >= 0),
+    winners INTEGER NOT NULL DEFAULT 1 CHECK (winners > 0),
+    draw_at TIMESTAMP NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'open',
+    -- Хеш серверного сида публикуется при создании, сам сид — после розыгрыша
+    server_seed CHAR(64) NOT NULL,
+    server_seed_hash CHAR(64) NOT NULL,
+    client_seed CHAR(64),
+    created_at TIMESTAMP NOT NULL,
+    drawn_at TIMESTAMP,
+    cancelled_at TIMESTAMP
+);
+
+CREATE INDEX idx_raffles_due ON raffles (draw_at) WHERE status = 'open';
+
+CREATE TABLE raffle_tickets (
+    id BIGSERIAL PRIMARY KEY,
+    raffle_id INTEGER NOT NULL REFERENCES raffles(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    cost INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_raffle_tickets_raffle ON raffle_tickets (raffle_id, user_id);
+
+CREATE TABLE raffle_winners (
+    raffle_id INTEGER NOT NULL REFERENCES raffles(id) ON DELETE CASCADE,
+    place INTEGER NOT NULL,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    nonce INTEGER NOT NULL,
+    digest CHAR(64) NOT NULL,
+    PRIMARY KEY (raffle_id, place)
+);
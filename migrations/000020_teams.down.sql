@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_user_tasks_completed;
+DROP TABLE IF EXISTS team_challenges;
+DROP TABLE IF EXISTS team_memberships;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,39 @@
+CREATE TABLE teams (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    invite_code VARCHAR(16) NOT NULL UNIQUE,
+    captain_id VARCHAR(36) REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL,
+    disbanded_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_teams_name ON teams (LOWER(name)) WHERE disbanded_at IS NULL;
+
+-- История членства: очки команды — это заработок участников за время, пока они в ней состояли
+CREATE TABLE team_memberships (
+    id BIGSERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP NOT NULL,
+    left_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_team_memberships_active ON team_memberships (user_id) WHERE left_at IS NULL;
+CREATE INDEX idx_team_memberships_team ON team_memberships (team_id, joined_at);
+
+CREATE TABLE team_challenges (
+    id SERIAL PRIMARY KEY,
+    team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE,
+    title VARCHAR(100) NOT NULL,
+    -- NULL — засчитывается любое задание
+    task_id VARCHAR(36) REFERENCES tasks(id),
+    goal INTEGER NOT NULL CHECK (goal > 0),
+    reward_points INTEGER NOT NULL CHECK (reward_points >= 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_team_challenges_team ON team_challenges (team_id, ends_at);
+CREATE INDEX idx_user_tasks_completed ON user_tasks (user_id, completed_at);
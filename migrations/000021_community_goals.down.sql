@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_tasks_task;
+DROP TABLE IF EXISTS community_goal_payouts;
+DROP TABLE IF EXISTS community_goals;
@@ -0,0 +1,25 @@
+CREATE TABLE community_goals (
+    id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    task_id VARCHAR(36) NOT NULL REFERENCES tasks(id),
+    goal INTEGER NOT NULL CHECK (goal > 0),
+    reward_points INTEGER NOT NULL CHECK (reward_points > 0),
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    reached_at TIMESTAMP,
+    -- Заполняется, когда награда выплачена всем участникам окна
+    rewarded_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_community_goals_pending ON community_goals (ends_at) WHERE rewarded_at IS NULL;
+
+-- Кому награда уже выплачена: выплата идёт пачками и переживает перезапуск
+CREATE TABLE community_goal_payouts (
+    goal_id INTEGER NOT NULL REFERENCES community_goals(id) ON DELETE CASCADE,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    paid_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (goal_id, user_id)
+);
+
+CREATE INDEX idx_user_tasks_task ON user_tasks (task_id, completed_at);
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS idx_user_tasks_task ON user_tasks (task_id, completed_at);
+DROP TABLE IF EXISTS task_completions;
+ALTER TABLE tasks DROP COLUMN IF EXISTS repeatable;
@@ -0,0 +1,20 @@
+-- Повторяемые задания можно выполнять сколько угодно раз, каждый раз с наградой
+ALTER TABLE tasks ADD COLUMN repeatable BOOLEAN NOT NULL DEFAULT FALSE;
+UPDATE tasks SET repeatable = TRUE WHERE name = 'ad';
+
+-- Журнал всех выполнений: user_tasks хранит одну строку на пользователя и задание
+CREATE TABLE task_completions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    task_id VARCHAR(36) NOT NULL REFERENCES tasks(id),
+    completed_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO task_completions (user_id, task_id, completed_at)
+SELECT user_id, task_id, completed_at FROM user_tasks;
+
+CREATE INDEX idx_task_completions_task ON task_completions (task_id, completed_at);
+CREATE INDEX idx_task_completions_user ON task_completions (user_id, completed_at);
+
+-- Прогресс общих целей теперь считается по журналу
+DROP INDEX IF EXISTS idx_user_tasks_task;
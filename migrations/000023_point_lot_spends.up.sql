@@ -0,0 +1,13 @@
+-- Какие партии израсходовало списание: по ним возврат и перевод восстанавливают
+-- очки с исходной датой начисления
+CREATE TABLE point_lot_spends (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason VARCHAR(50) NOT NULL,
+    reference_id VARCHAR(100),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    earned_at TIMESTAMP NOT NULL,
+    spent_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_point_lot_spends_reference ON point_lot_spends (reason, reference_id);